@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	ASCII85_PREFIX = "<~"
+	ASCII85_SUFFIX = "~>"
+)
+
+var ASCII85_ENCODE_MAP = makeAscii85EncodeMap()
+
+var Z85_ENCODE_MAP = []byte(
+	"0123456789" +
+		"abcdefghijklmnopqrstuvwxyz" +
+		"ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
+		".-:+=^!/*?&<>()[]{}@%$#",
+)
+
+var Z85_DECODE_MAP = makeDecodeMap(Z85_ENCODE_MAP)
+
+// makeAscii85EncodeMap returns the 85 contiguous characters from '!' to 'u'.
+func makeAscii85EncodeMap() []byte {
+	m := make([]byte, 85)
+	for i := range m {
+		m[i] = byte('!' + i)
+	}
+
+	return m
+}
+
+func base85EncodeGroup(v uint32, encoded []byte, charmap []byte) {
+	for i := 4; i >= 0; i-- {
+		encoded[i] = charmap[v%85]
+		v /= 85
+	}
+}
+
+func base85DecodeGroup(in []byte) (uint32, error) {
+	v := uint64(0)
+	for _, d := range in {
+		v = v*85 + uint64(d)
+	}
+
+	if v > 0xffffffff {
+		return 0, errors.New("base85 group overflows 32 bits")
+	}
+
+	return uint32(v), nil
+}
+
+// Ascii85EncodeFile encodes in with the btoa / Adobe variant of Ascii85,
+// which uses 'z' for all-zero groups and optionally wraps the output with
+// the "<~" and "~>" delimiters.
+func Ascii85EncodeFile(in io.Reader, out io.Writer, delimit bool) error {
+	reader := bufio.NewReader(in)
+	buf := make([]byte, 4)
+	encoded := make([]byte, 5)
+
+	if delimit {
+		if _, err := out.Write([]byte(ASCII85_PREFIX)); err != nil {
+			return err
+		}
+	}
+
+	for {
+		buf[0], buf[1], buf[2], buf[3] = 0, 0, 0, 0
+		n, err := io.ReadFull(reader, buf)
+		if n == 0 {
+			if err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+			break
+		}
+
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+
+		v := uint32(buf[0])<<24 | uint32(buf[1])<<16 | uint32(buf[2])<<8 | uint32(buf[3])
+		if n == 4 && v == 0 {
+			_, err = out.Write([]byte{'z'})
+		} else {
+			base85EncodeGroup(v, encoded, ASCII85_ENCODE_MAP)
+			_, err = out.Write(encoded[:n+1])
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if n < 4 {
+			break
+		}
+	}
+
+	if delimit {
+		if _, err := out.Write([]byte(ASCII85_SUFFIX)); err != nil {
+			return err
+		}
+	}
+
+	_, err := out.Write([]byte("\n"))
+	return err
+}
+
+// Ascii85DecodeFile decodes Ascii85 data, the "<~" and "~>" delimiters are
+// optional, and anything after "~>" is ignored.
+func Ascii85DecodeFile(file io.Reader, output io.Writer) error {
+	in := make([]byte, 5)
+	out := make([]byte, 4)
+	i := 0
+	reader := bufio.NewReader(file)
+
+	first := true
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return err
+			}
+			break
+		}
+
+		if isSpace(b) {
+			continue
+		}
+
+		if first {
+			first = false
+			if b == '<' {
+				next, err := reader.ReadByte()
+				if err != nil || next != '~' {
+					return errors.New("invalid ascii85 prefix")
+				}
+				continue
+			}
+		}
+
+		if b == '~' {
+			next, err := reader.ReadByte()
+			if err != nil || next != '>' {
+				return errors.New("invalid ascii85 suffix")
+			}
+			break
+		}
+
+		if b == 'z' {
+			if i != 0 {
+				return errors.New("ascii85 'z' inside a group")
+			}
+
+			if _, err := output.Write([]byte{0, 0, 0, 0}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if b < '!' || b > 'u' {
+			return fmt.Errorf("invalid ascii85 character '%c'", b)
+		}
+
+		in[i] = b - '!'
+		i++
+		if i < 5 {
+			continue
+		}
+
+		v, err := base85DecodeGroup(in)
+		if err != nil {
+			return err
+		}
+
+		out[0], out[1], out[2], out[3] = byte(v>>24), byte(v>>16), byte(v>>8), byte(v)
+		if _, err := output.Write(out); err != nil {
+			return err
+		}
+		i = 0
+	}
+
+	if i == 0 {
+		return nil
+	}
+
+	if i == 1 {
+		return errors.New("truncated ascii85 group")
+	}
+
+	for j := i; j < 5; j++ {
+		in[j] = 'u' - '!'
+	}
+
+	v, err := base85DecodeGroup(in)
+	if err != nil {
+		return err
+	}
+
+	out[0], out[1], out[2], out[3] = byte(v>>24), byte(v>>16), byte(v>>8), byte(v)
+	_, err = output.Write(out[:i-1])
+	return err
+}
+
+// Z85EncodeFile encodes in with the ZeroMQ Z85 alphabet, the input length
+// must be a multiple of 4 bytes as required by the specification.
+func Z85EncodeFile(in io.Reader, out io.Writer) error {
+	reader := bufio.NewReader(in)
+	buf := make([]byte, 4)
+	encoded := make([]byte, 5)
+
+	for {
+		n, err := io.ReadFull(reader, buf)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return fmt.Errorf("z85 input length is not a multiple of 4, %d bytes left", n)
+			}
+			return err
+		}
+
+		v := uint32(buf[0])<<24 | uint32(buf[1])<<16 | uint32(buf[2])<<8 | uint32(buf[3])
+		base85EncodeGroup(v, encoded, Z85_ENCODE_MAP)
+		if _, err := out.Write(encoded); err != nil {
+			return err
+		}
+	}
+
+	_, err := out.Write([]byte("\n"))
+	return err
+}
+
+func Z85DecodeFile(file io.Reader, output io.Writer) error {
+	in := make([]byte, 5)
+	out := make([]byte, 4)
+	i := 0
+	reader := bufio.NewReader(file)
+
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return err
+			}
+			break
+		}
+
+		if isSpace(b) {
+			continue
+		}
+
+		v := Z85_DECODE_MAP[b]
+		if v == 0xff {
+			return fmt.Errorf("invalid z85 character '%c'", b)
+		}
+
+		in[i] = v
+		i++
+		if i < 5 {
+			continue
+		}
+
+		n, err := base85DecodeGroup(in)
+		if err != nil {
+			return err
+		}
+
+		out[0], out[1], out[2], out[3] = byte(n>>24), byte(n>>16), byte(n>>8), byte(n)
+		if _, err := output.Write(out); err != nil {
+			return err
+		}
+		i = 0
+	}
+
+	if i != 0 {
+		return fmt.Errorf("z85 input length is not a multiple of 5, %d characters left", i)
+	}
+
+	return nil
+}
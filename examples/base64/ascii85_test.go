@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestAscii85Encode(t *testing.T) {
+	cases := []struct {
+		data    string
+		encoded string
+	}{
+		{"", "<~~>"},
+		{".", "<~/c~>"},
+		{"Man is distinguished", "<~9jqo^BlbD-BleB1DJ+*+F(f,q~>"},
+		{"\x00\x00\x00\x00abc\x00\x00\x00\x00\x00", "<~z@:E^Hz~>"},
+	}
+
+	for _, c := range cases {
+		out := bytes.NewBuffer(nil)
+		err := Ascii85EncodeFile(strings.NewReader(c.data), out, true)
+		if err != nil {
+			t.Fatalf("encode %q failed: %s", c.data, err)
+		}
+
+		got := strings.TrimSuffix(out.String(), "\n")
+		if got != c.encoded {
+			t.Errorf("encode %q got %s; expected %s", c.data, got, c.encoded)
+		}
+
+		out.Reset()
+		err = Ascii85DecodeFile(strings.NewReader(c.encoded), out)
+		if err != nil {
+			t.Fatalf("decode %s failed: %s", c.encoded, err)
+		}
+
+		if out.String() != c.data {
+			t.Errorf("decode %s got %q; expected %q", c.encoded, out.String(), c.data)
+		}
+	}
+}
+
+func TestAscii85DecodeWithoutDelimiters(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	err := Ascii85DecodeFile(strings.NewReader("9jqo^Blb\nD-BleB1DJ+*+F(f,q\n"), out)
+	if err != nil {
+		t.Fatalf("decode failed: %s", err)
+	}
+
+	if out.String() != "Man is distinguished" {
+		t.Errorf("got %q", out.String())
+	}
+}
+
+func TestAscii85DecodeErrors(t *testing.T) {
+	cases := []string{
+		"<~9jz~>",    // z inside a group
+		"<~9jqo^v~>", // character out of range
+		"<~s8W-#~>",  // group overflows 32 bits
+		"<~9jqo^B~>", // single trailing character
+		"<~9jqo^~",   // broken suffix
+	}
+
+	for _, c := range cases {
+		out := bytes.NewBuffer(nil)
+		err := Ascii85DecodeFile(strings.NewReader(c), out)
+		if err == nil {
+			t.Errorf("decode %s expected error", c)
+		}
+	}
+}
+
+func TestZ85Encode(t *testing.T) {
+	// Test vector from https://rfc.zeromq.org/spec/32/
+	data := []byte{0x86, 0x4f, 0xd2, 0x6f, 0xb5, 0x59, 0xf7, 0x5b}
+	exp := "HelloWorld"
+
+	out := bytes.NewBuffer(nil)
+	err := Z85EncodeFile(bytes.NewReader(data), out)
+	if err != nil {
+		t.Fatalf("encode failed: %s", err)
+	}
+
+	got := strings.TrimSuffix(out.String(), "\n")
+	if got != exp {
+		t.Errorf("got %s; expected %s", got, exp)
+	}
+
+	out.Reset()
+	err = Z85DecodeFile(strings.NewReader(exp), out)
+	if err != nil {
+		t.Fatalf("decode failed: %s", err)
+	}
+
+	if !bytes.Equal(out.Bytes(), data) {
+		t.Errorf("got %x; expected %x", out.Bytes(), data)
+	}
+}
+
+func TestZ85InvalidLength(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := Z85EncodeFile(strings.NewReader("abc"), out); err == nil {
+		t.Errorf("encode 3 bytes expected error")
+	}
+
+	if err := Z85DecodeFile(strings.NewReader("Hello"+"Wor"), out); err == nil {
+		t.Errorf("decode 8 characters expected error")
+	}
+}
+
+func TestBase85RoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(85))
+	for size := 0; size < 64; size++ {
+		data := make([]byte, size*4)
+		r.Read(data)
+		if size%5 == 0 && len(data) >= 8 {
+			copy(data[4:8], []byte{0, 0, 0, 0})
+		}
+
+		for _, n := range []int{len(data), len(data) - size%4, size} {
+			encoded := bytes.NewBuffer(nil)
+			decoded := bytes.NewBuffer(nil)
+			if err := Ascii85EncodeFile(bytes.NewReader(data[:n]), encoded, true); err != nil {
+				t.Fatalf("ascii85 encode failed: %s", err)
+			}
+
+			if err := Ascii85DecodeFile(encoded, decoded); err != nil {
+				t.Fatalf("ascii85 decode failed: %s", err)
+			}
+
+			if !bytes.Equal(decoded.Bytes(), data[:n]) {
+				t.Errorf("ascii85 round trip of %d bytes mismatch", n)
+			}
+		}
+
+		encoded := bytes.NewBuffer(nil)
+		decoded := bytes.NewBuffer(nil)
+		if err := Z85EncodeFile(bytes.NewReader(data), encoded); err != nil {
+			t.Fatalf("z85 encode failed: %s", err)
+		}
+
+		if err := Z85DecodeFile(encoded, decoded); err != nil {
+			t.Fatalf("z85 decode failed: %s", err)
+		}
+
+		if !bytes.Equal(decoded.Bytes(), data) {
+			t.Errorf("z85 round trip of %d bytes mismatch", len(data))
+		}
+	}
+}
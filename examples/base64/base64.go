@@ -36,100 +36,124 @@ var BASE64_ENCODE_URLSAFE_MAP = []byte{
 	'4', '5', '6', '7', '8', '9', '-', '_', // 56-63
 }
 
-var BASE64_DECODE_MAP = []byte{
-	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-	// SP    !     "     #     $     %     &     '     (     )     *     +     ,     -     .     /
-	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x3e, 0x3f, 0x3e, 0x00, 0x3f,
-	// 0     1     2     3     4     5     6     7     8     9     :     ;     <     =     >     ?
-	0x34, 0x35, 0x36, 0x37, 0x38, 0x39, 0x3a, 0x3b, 0x3c, 0x3d, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-	// @     A     B     C     D     E     F     G     H     I     J     K     L     M     N     O
-	0x00, 0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e,
-	// P     Q     R     S     T     U     V     W     X     Y     Z     [     \     ]     ^     _
-	0x0f, 0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x00, 0x00, 0x00, 0x00, 0x3f,
-	// `     a     b     c     d     e     f     g     h     i     j     k     l     m     n     o
-	0x00, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f, 0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28,
-	// p     q     r     s     t     u     v     w     x     y     z     {     |     }     ~   DEL
-	0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f, 0x30, 0x31, 0x32, 0x33, 0x00, 0x00, 0x00, 0x00, 0x00,
-	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-}
+var BASE64_ENCODE_BCRYPT_MAP = []byte("./ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789")
+
+var BASE64_ENCODE_CRYPT_MAP = []byte("./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz")
 
-type LineBreakWriter struct {
-	writer *bufio.Writer
-	count  int
-	width  int
+var BASE64_ENCODE_IMAP_MAP = []byte("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+,")
+
+// BASE64_ALPHABETS are the named presets accepted by -alphabet.
+var BASE64_ALPHABETS = map[string][]byte{
+	"standard": BASE64_ENCODE_STANDARD_MAP,
+	"url":      BASE64_ENCODE_URLSAFE_MAP,
+	"bcrypt":   BASE64_ENCODE_BCRYPT_MAP,
+	"crypt":    BASE64_ENCODE_CRYPT_MAP,
+	"imap":     BASE64_ENCODE_IMAP_MAP,
 }
 
-func NewLineBreakWriter(writer io.Writer, width int) *LineBreakWriter {
-	w := &LineBreakWriter{
-		writer: bufio.NewWriter(writer),
-		count:  0,
-		width:  width,
+// BASE64_DECODE_MAP accepts both standard and URL safe characters.
+var BASE64_DECODE_MAP = makeDecodeMap(BASE64_ENCODE_STANDARD_MAP, BASE64_ENCODE_URLSAFE_MAP)
+
+// makeDecodeMap builds a reverse lookup table of charmaps, characters not in
+// any charmap are marked as 0xff.
+func makeDecodeMap(charmaps ...[]byte) []byte {
+	m := make([]byte, 256)
+	for i := range m {
+		m[i] = 0xff
+	}
+
+	for _, charmap := range charmaps {
+		for i, c := range charmap {
+			m[c] = byte(i)
+		}
 	}
 
-	return w
+	return m
 }
 
-func (w *LineBreakWriter) ToFile(filename string) error {
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
+func isSpace(b byte) bool {
+	return b == '\n' || b == '\r' || b == ' ' || b == '\t'
+}
+
+// LookupAlphabet returns a preset alphabet by name, or validates name itself
+// as a 64-character alphabet.
+func LookupAlphabet(name string) ([]byte, error) {
+	if charmap, found := BASE64_ALPHABETS[name]; found {
+		return charmap, nil
 	}
 
-	w.writer = bufio.NewWriter(file)
-	return nil
+	charmap := []byte(name)
+	if err := ValidateAlphabet(charmap); err != nil {
+		return nil, err
+	}
+
+	return charmap, nil
 }
 
-func (w *LineBreakWriter) Write(data []byte) (int, error) {
-	c := 0
-	var err error
+func ValidateAlphabet(charmap []byte) error {
+	if len(charmap) != 64 {
+		return fmt.Errorf("alphabet must have 64 characters, got %d", len(charmap))
+	}
 
-	for _, b := range data {
-		err = w.writer.WriteByte(b)
-		if err != nil {
-			break
-		}
+	seen := make([]int, 256)
+	for i, c := range charmap {
+		switch {
+		case c <= ' ' || c >= 0x7f:
+			return fmt.Errorf("alphabet character 0x%02x at %d is not printable", c, i)
 
-		c += 1
-		w.count += 1
-		if w.width > 0 && w.count >= w.width {
-			err = w.writer.WriteByte('\n')
-			if err != nil {
-				break
-			}
-			w.count = 0
+		case c == '=':
+			return fmt.Errorf("alphabet character '=' at %d is reserved for padding", i)
+
+		case seen[c] > 0:
+			return fmt.Errorf("alphabet character '%c' at %d duplicates position %d", c, i, seen[c]-1)
 		}
+
+		seen[c] = i + 1
 	}
 
-	return c, err
+	return nil
 }
 
-func (w *LineBreakWriter) Flush() {
-	w.writer.Flush()
+type PaddingPolicy int
+
+const (
+	PaddingAuto PaddingPolicy = iota
+	PaddingRequired
+	PaddingForbidden
+)
+
+func ParsePaddingPolicy(name string) (PaddingPolicy, error) {
+	switch name {
+	case "auto":
+		return PaddingAuto, nil
+	case "required":
+		return PaddingRequired, nil
+	case "forbidden":
+		return PaddingForbidden, nil
+	default:
+		return PaddingAuto, fmt.Errorf("unknown padding policy '%s'", name)
+	}
 }
 
-func Base64EncodeFile(in io.Reader, out io.Writer, charmap []byte) error {
+// Base64EncodeFile encodes in with charmap, the last group is padded with '='
+// unless pad is false, as RawStdEncoding and RawURLEncoding do.
+func Base64EncodeFile(in io.Reader, out io.Writer, charmap []byte, pad bool) error {
+	reader := bufio.NewReader(in)
 	buf := make([]byte, 3)
 	encoded := make([]byte, 4)
-	defer func() {
-		_, _ = out.Write([]byte("\n"))
-	}()
 
 	for {
 		buf[1] = 0
 		buf[2] = 0
-		n, err := in.Read(buf)
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				return nil
+		n, err := io.ReadFull(reader, buf)
+		if n == 0 {
+			if err != nil && !errors.Is(err, io.EOF) {
+				return err
 			}
+			break
+		}
+
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
 			return err
 		}
 
@@ -138,24 +162,46 @@ func Base64EncodeFile(in io.Reader, out io.Writer, charmap []byte) error {
 		encoded[2] = charmap[((buf[1]&0x0f)<<2)|((buf[2]&0xc0)>>6)]
 		encoded[3] = charmap[(buf[2]&0x3f)>>0]
 
+		size := 4
 		if n < 3 {
 			encoded[3] = '='
 			if n < 2 {
 				encoded[2] = '='
 			}
+
+			if !pad {
+				size = n + 1
+			}
 		}
 
-		_, err = out.Write(encoded)
+		_, err = out.Write(encoded[:size])
 		if err != nil {
 			return err
 		}
+
+		if n < 3 {
+			break
+		}
 	}
+
+	_, err := out.Write([]byte("\n"))
+	return err
+}
+
+func base64DecodeGroup(in []byte, out []byte, size int) []byte {
+	out[0] = ((in[0] & 0x3f) << 2) | ((in[1] & 0x30) >> 4)
+	out[1] = ((in[1] & 0x0f) << 4) | ((in[2] & 0x3c) >> 2)
+	out[2] = ((in[2] & 0x03) << 6) | ((in[3] & 0x3f) >> 0)
+	return out[:size-1]
 }
 
-func Base64DecodeFile(file io.Reader, output io.Writer) error {
+// Base64DecodeFile decodes base64 data with decodeMap, whitespace is ignored
+// and the trailing '=' padding is accepted or rejected according to padding.
+func Base64DecodeFile(file io.Reader, output io.Writer, decodeMap []byte, padding PaddingPolicy) error {
 	in := make([]byte, 4)
 	out := make([]byte, 3)
 	i := 0
+	pads := 0
 	reader := bufio.NewReader(file)
 	for {
 		b, err := reader.ReadByte()
@@ -163,36 +209,35 @@ func Base64DecodeFile(file io.Reader, output io.Writer) error {
 			if !errors.Is(err, io.EOF) {
 				return err
 			}
+			break
+		}
 
-			out = make([]byte, 0, 3)
-			if i > 0 {
-				out = append(out, ((in[0] & 0x3f) << 2))
-			}
+		if isSpace(b) {
+			continue
+		}
 
-			if i > 1 {
-				out[1] |= ((in[1] & 0x30) >> 4)
-				out = append(out, ((in[1] & 0x0f) << 4))
+		if b == '=' {
+			if padding == PaddingForbidden {
+				return errors.New("unexpected base64 padding")
 			}
 
-			if i > 2 {
-				out[2] |= ((in[2] & 0x3c) >> 2)
-				out = append(out, ((in[2] & 0x03) << 6))
+			if i+pads < 2 || i+pads >= 4 {
+				return errors.New("misplaced base64 padding")
 			}
 
-			_, err = output.Write(out)
-			return err
-		}
-
-		v := BASE64_DECODE_MAP[b]
-		switch {
-		case b == '\n', b == '\r', b == ' ', b == '\t':
+			pads++
 			continue
+		}
 
-		case b == '=', b == 'A':
-		case v == 0:
+		v := decodeMap[b]
+		if v == 0xff {
 			return fmt.Errorf("invalid base64 character '%c'", b)
 		}
 
+		if pads > 0 {
+			return errors.New("base64 data after padding")
+		}
+
 		in[i] = v
 		i++
 
@@ -200,15 +245,33 @@ func Base64DecodeFile(file io.Reader, output io.Writer) error {
 			continue
 		}
 
-		out[0] = ((in[0] & 0x3f) << 2) | ((in[1] & 0x30) >> 4)
-		out[1] = ((in[1] & 0x0f) << 4) | ((in[2] & 0x3c) >> 2)
-		out[2] = ((in[2] & 0x03) << 6) | ((in[3] & 0x3f) >> 0)
-		_, err = output.Write(out)
+		_, err = output.Write(base64DecodeGroup(in, out, 4))
 		if err != nil {
 			return err
 		}
 		i = 0
 	}
+
+	switch {
+	case i == 0 && pads == 0:
+		return nil
+
+	case i == 1:
+		return errors.New("truncated base64 group")
+
+	case pads > 0 && i+pads != 4:
+		return errors.New("incomplete base64 padding")
+
+	case pads == 0 && padding == PaddingRequired:
+		return errors.New("missing base64 padding")
+	}
+
+	for j := i; j < 4; j++ {
+		in[j] = 0
+	}
+
+	_, err := output.Write(base64DecodeGroup(in, out, i))
+	return err
 }
 
 func openFile(name string) (io.ReadCloser, error) {
@@ -221,56 +284,368 @@ func openFile(name string) (io.ReadCloser, error) {
 
 func usage() {
 	name := os.Args[0]
-	fmt.Printf("Usage: %s [-e | -d] file1 [file2 ...]\n", name)
+	fmt.Printf("Usage: %s [-e | -d] [-t base64|ascii85|z85|uu|xx] [-alphabet name] [-pem label] [-extract] [-check] file1 [file2 ...]\n", name)
+	flag.PrintDefaults()
 }
 
-type FileEncodeHandler func(io.Reader) error
+type Base64Configure struct {
+	Decode   bool
+	Width    int
+	URLSafe  bool
+	Output   string
+	Encoding string
+	Alphabet string
+	NoPad    bool
+	Padding  string
+	PEM      string
+	CRLF     bool
+	Prefix   string
+	Name     string
+	UseName  bool
+	PerFile  bool
+	Headers  bool
+	Jobs     int
+	Extract  bool
+	Check    bool
+	MinLen   int
+	Dir      string
+	Files    []string
+}
 
-func main() {
-	modeDecode := flag.Bool("d", false, "decode mode")
-	width := flag.Int("b", 0, "width of encoded line, 0 means no line break, usually 64 or 76")
-	urlsafe := flag.Bool("u", false, "use URL safe encoding")
-	output := flag.String("o", "", "output to file")
-	flag.Usage = usage
-	flag.Parse()
+func initFlags(conf *Base64Configure) {
+	flag.BoolVar(&conf.Decode, "d", false, "decode mode")
+	flag.IntVar(&conf.Width, "b", 0, "width of encoded line, 0 means no line break, usually 64 or 76")
+	flag.BoolVar(&conf.URLSafe, "u", false, "use URL safe encoding")
+	flag.StringVar(&conf.Output, "o", "", "output to file")
+	flag.StringVar(&conf.Encoding, "t", "base64", "encoding type, base64, ascii85, z85, uu or xx")
+	flag.StringVar(&conf.Alphabet, "alphabet", "",
+		"64-character alphabet or preset name, standard, url, bcrypt, crypt or imap")
+	flag.BoolVar(&conf.NoPad, "nopad", false, "do not write '=' padding when encoding")
+	flag.StringVar(&conf.Padding, "padding", "auto", "padding policy of decoding, auto, required or forbidden")
+	flag.StringVar(&conf.PEM, "pem", "",
+		"wrap output in a PEM block with this label, or extract blocks with this label when decoding, '*' for any")
+	flag.BoolVar(&conf.CRLF, "crlf", false, "use CRLF line endings in encoded output")
+	flag.StringVar(&conf.Prefix, "prefix", "", "prefix of each encoded line, such as indentation")
+	flag.StringVar(&conf.Name, "name", "", "file name in uuencode header, default to the input file name")
+	flag.BoolVar(&conf.UseName, "usename", false,
+		"write decoded data to the file named in the uuencode or begin-base64 header instead of output")
+	flag.BoolVar(&conf.PerFile, "i", false,
+		"write each input to its own file, <name>.b64 when encoding, <name> without .b64 when decoding")
+	flag.IntVar(&conf.Jobs, "j", 1, "number of goroutines to encode or decode base64, 0 means the number of CPUs")
+	flag.BoolVar(&conf.Extract, "extract", false,
+		"find base64 runs and data URIs in text such as JSON and logs, and report or save decoded data")
+	flag.BoolVar(&conf.Check, "check", false,
+		"validate base64 input and report the line and column of every problem, exit with 1 if any")
+	flag.IntVar(&conf.MinLen, "minlen", EXTRACT_MIN_LENGTH, "minimum length of base64 runs to extract")
+	flag.StringVar(&conf.Dir, "dir", "", "directory to save extracted data, report with preview if empty")
+	flag.BoolVar(&conf.Headers, "headers", false,
+		"put each file in a 'begin-base64 <mode> <name>' block, so multiple files share one stream")
+}
+
+type FileEncodeHandler func(in io.Reader, filename string) error
 
+// base64Maps returns the encode and decode maps selected by -u and -alphabet.
+func base64Maps(conf *Base64Configure) ([]byte, []byte, error) {
 	charmap := BASE64_ENCODE_STANDARD_MAP
-	if *urlsafe {
+	decodeMap := BASE64_DECODE_MAP
+	if conf.URLSafe {
 		charmap = BASE64_ENCODE_URLSAFE_MAP
 	}
 
-	out := NewLineBreakWriter(os.Stdout, *width)
-	defer out.Flush()
-	if *output != "" {
-		err := out.ToFile(*output)
+	if conf.Alphabet != "" {
+		var err error
+		charmap, err = LookupAlphabet(conf.Alphabet)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		decodeMap = makeDecodeMap(charmap)
+	}
+
+	return charmap, decodeMap, nil
+}
+
+func makeCheckHandler(conf *Base64Configure, out io.Writer) (FileEncodeHandler, error) {
+	_, decodeMap, err := base64Maps(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.URLSafe && conf.Alphabet == "" {
+		decodeMap = makeDecodeMap(BASE64_ENCODE_URLSAFE_MAP)
+	}
+
+	padding, err := ParsePaddingPolicy(conf.Padding)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(in io.Reader, filename string) error {
+		return CheckBase64File(in, out, filename, decodeMap, padding)
+	}, nil
+}
+
+func makeBase64Handler(conf *Base64Configure, out io.Writer) (FileEncodeHandler, error) {
+	charmap, decodeMap, err := base64Maps(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.Headers {
+		return makeHeadersHandler(conf, out)
+	}
+
+	if conf.Decode {
+		padding, err := ParsePaddingPolicy(conf.Padding)
+		if err != nil {
+			return nil, err
+		}
+
+		if conf.Jobs != 1 {
+			return func(in io.Reader, _ string) error {
+				return Base64DecodeParallel(in, out, decodeMap, padding, conf.Jobs)
+			}, nil
+		}
+
+		return func(in io.Reader, _ string) error { return Base64DecodeFile(in, out, decodeMap, padding) }, nil
+	}
+
+	if conf.Jobs != 1 {
+		return func(in io.Reader, _ string) error {
+			return Base64EncodeParallel(in, out, charmap, !conf.NoPad, conf.Jobs)
+		}, nil
+	}
+
+	return func(in io.Reader, _ string) error { return Base64EncodeFile(in, out, charmap, !conf.NoPad) }, nil
+}
+
+func makeHeadersHandler(conf *Base64Configure, out io.Writer) (FileEncodeHandler, error) {
+	if conf.Decode {
+		return func(in io.Reader, _ string) error {
+			return Base64DecodeWithHeaders(in, out, conf.UseName)
+		}, nil
+	}
+
+	return func(in io.Reader, filename string) error {
+		header := NewUUHeader(filename)
+		if conf.Name != "" {
+			header.Name = conf.Name
+		}
+
+		return Base64EncodeWithHeader(in, out, header)
+	}, nil
+}
+
+func makePemHandler(conf *Base64Configure, out io.Writer) (FileEncodeHandler, error) {
+	if conf.Decode {
+		return func(in io.Reader, _ string) error { return PemDecodeFile(in, out, conf.PEM) }, nil
+	}
+
+	if conf.PEM == PEM_LABEL_MATCH {
+		return nil, errors.New("PEM label is required when encoding")
+	}
+
+	// CRLF is applied by LineBreakWriter
+	return func(in io.Reader, _ string) error { return PemEncodeFile(in, out, conf.PEM, "\n") }, nil
+}
+
+func makeUUHandler(conf *Base64Configure, out io.Writer, charmap []byte) (FileEncodeHandler, error) {
+	if conf.Decode {
+		decodeMap := UU_DECODE_MAP
+		if conf.Encoding == "xx" {
+			decodeMap = XX_DECODE_MAP
+		}
+
+		return func(in io.Reader, _ string) error {
+			return UUDecodeFile(in, out, decodeMap, conf.UseName)
+		}, nil
+	}
+
+	return func(in io.Reader, filename string) error {
+		header := NewUUHeader(filename)
+		if conf.Name != "" {
+			header.Name = conf.Name
+		}
+
+		return UUEncodeFile(in, out, charmap, header)
+	}, nil
+}
+
+func makeHandler(conf *Base64Configure, out io.Writer) (FileEncodeHandler, error) {
+	// reports are not encoded data, so they are not written to per-file
+	// outputs
+	if conf.PerFile && (conf.Check || conf.Extract) {
+		return nil, errors.New("-i does not work with -check or -extract")
+	}
+
+	if conf.Extract {
+		return func(in io.Reader, filename string) error {
+			return ExtractBase64File(in, out, filename, conf.MinLen, conf.Dir)
+		}, nil
+	}
+
+	if conf.Check && conf.Encoding != "base64" {
+		return nil, fmt.Errorf("-check does not work with encoding '%s'", conf.Encoding)
+	}
+
+	switch conf.Encoding {
+	case "base64":
+		if conf.Check {
+			return makeCheckHandler(conf, out)
+		}
+
+		if conf.PEM != "" {
+			return makePemHandler(conf, out)
+		}
+		return makeBase64Handler(conf, out)
+
+	case "ascii85":
+		if conf.Decode {
+			return func(in io.Reader, _ string) error { return Ascii85DecodeFile(in, out) }, nil
+		}
+		return func(in io.Reader, _ string) error { return Ascii85EncodeFile(in, out, true) }, nil
+
+	case "z85":
+		if conf.Decode {
+			return func(in io.Reader, _ string) error { return Z85DecodeFile(in, out) }, nil
+		}
+		return func(in io.Reader, _ string) error { return Z85EncodeFile(in, out) }, nil
+
+	case "uu":
+		return makeUUHandler(conf, out, UU_ENCODE_MAP)
+
+	case "xx":
+		return makeUUHandler(conf, out, XX_ENCODE_MAP)
+
+	default:
+		return nil, fmt.Errorf("unknown encoding '%s'", conf.Encoding)
+	}
+}
+
+// configureOutput applies line options to out, decoded data is written as is.
+func configureOutput(conf *Base64Configure, out *LineBreakWriter) {
+	if conf.Decode {
+		return
+	}
+
+	if conf.CRLF {
+		out.SetLineEnding("\r\n")
+	}
+
+	out.SetPrefix(conf.Prefix)
+}
+
+// encodePerFile encodes or decodes filename into its own output file.
+func encodePerFile(conf *Base64Configure, filename string) error {
+	target, err := PerFileOutputName(filename, conf.Encoding, conf.Decode)
+	if err != nil {
+		return err
+	}
+
+	file, err := openFile(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	out, err := NewLineBreakFileWriter(target, conf.Width)
+	if err != nil {
+		return err
+	}
+
+	configureOutput(conf, out)
+	handler, err := makeHandler(conf, out)
+	if err == nil {
+		err = handler(file, filename)
+	}
+
+	if errClose := out.Close(); err == nil {
+		err = errClose
+	}
+
+	return err
+}
+
+// run processes all files in conf and returns the exit status, 1 if any
+// error occurs.
+func run(conf *Base64Configure) (status int) {
+	if conf.Decode || conf.Extract || conf.Check ||
+		conf.PEM != "" || conf.Headers || conf.Encoding == "uu" || conf.Encoding == "xx" {
+		// decoded data is never wrapped, PEM, begin-base64 and uuencode
+		// formats have their own fixed line length
+		conf.Width = 0
+	}
+
+	if conf.PerFile {
+		if _, err := makeHandler(conf, io.Discard); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			return 1
+		}
+
+		for _, filename := range conf.Files {
+			if err := encodePerFile(conf, filename); err != nil {
+				fmt.Printf("ERROR: %s: %s\n", filename, err)
+				status = 1
+			}
+		}
+		return status
+	}
+
+	out := NewLineBreakWriter(os.Stdout, conf.Width)
+	configureOutput(conf, out)
+	defer func() {
+		if err := out.Close(); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			status = 1
+		}
+	}()
+
+	if conf.Output != "" {
+		err := out.ToFile(conf.Output)
 		if err != nil {
 			fmt.Printf("ERROR: %s\n", err)
-			return
+			return 1
 		}
 	}
 
-	fileList := []string{"-"}
-	if flag.NArg() > 0 {
-		fileList = flag.Args()
+	handler, err := makeHandler(conf, out)
+	if err != nil {
+		fmt.Printf("ERROR: %s\n", err)
+		return 1
 	}
 
-	for _, filename := range fileList {
+	for _, filename := range conf.Files {
 		file, err := openFile(filename)
 		if err != nil {
 			fmt.Printf("Open file '%s' failed: %s\n", filename, err)
+			status = 1
 			continue
 		}
 
 		defer file.Close()
 
-		if *modeDecode {
-			err = Base64DecodeFile(file, out)
-		} else {
-			err = Base64EncodeFile(file, out, charmap)
-		}
-
+		err = handler(file, filename)
 		if err != nil {
+			// keep the output of the handler before the error message
+			_ = out.Flush()
 			fmt.Printf("ERROR: %s\n", err)
+			status = 1
 		}
 	}
+
+	return status
+}
+
+func main() {
+	conf := &Base64Configure{}
+	initFlags(conf)
+	flag.Usage = usage
+	flag.Parse()
+
+	conf.Files = []string{"-"}
+	if flag.NArg() > 0 {
+		conf.Files = flag.Args()
+	}
+
+	os.Exit(run(conf))
 }
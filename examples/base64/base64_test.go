@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestBase64Alphabets(t *testing.T) {
+	r := rand.New(rand.NewSource(64))
+	data := make([]byte, 300)
+	r.Read(data)
+
+	for name, charmap := range BASE64_ALPHABETS {
+		if err := ValidateAlphabet(charmap); err != nil {
+			t.Fatalf("preset %s is invalid: %s", name, err)
+		}
+
+		exp := base64.NewEncoding(string(charmap)).EncodeToString(data)
+
+		out := bytes.NewBuffer(nil)
+		err := Base64EncodeFile(bytes.NewReader(data), out, charmap, true)
+		if err != nil {
+			t.Fatalf("preset %s encode failed: %s", name, err)
+		}
+
+		got := strings.TrimSuffix(out.String(), "\n")
+		if got != exp {
+			t.Errorf("preset %s got %s; expected %s", name, got, exp)
+		}
+
+		out.Reset()
+		err = Base64DecodeFile(strings.NewReader(exp), out, makeDecodeMap(charmap), PaddingAuto)
+		if err != nil {
+			t.Fatalf("preset %s decode failed: %s", name, err)
+		}
+
+		if !bytes.Equal(out.Bytes(), data) {
+			t.Errorf("preset %s round trip mismatch", name)
+		}
+	}
+}
+
+func TestBase64CustomAlphabet(t *testing.T) {
+	// reversed standard alphabet
+	alphabet := "/+9876543210zyxwvutsrqponmlkjihgfedcbaZYXWVUTSRQPONMLKJIHGFEDCBA"
+	charmap, err := LookupAlphabet(alphabet)
+	if err != nil {
+		t.Fatalf("lookup alphabet failed: %s", err)
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := Base64EncodeFile(strings.NewReader("hello!"), out, charmap, true); err != nil {
+		t.Fatalf("encode failed: %s", err)
+	}
+
+	exp := base64.NewEncoding(alphabet).EncodeToString([]byte("hello!")) + "\n"
+	if out.String() != exp {
+		t.Errorf("got %s; expected %s", out.String(), exp)
+	}
+
+	out.Reset()
+	err = Base64DecodeFile(strings.NewReader(exp), out, makeDecodeMap(charmap), PaddingAuto)
+	if err != nil {
+		t.Fatalf("decode failed: %s", err)
+	}
+
+	if out.String() != "hello!" {
+		t.Errorf("got %q; expected %q", out.String(), "hello!")
+	}
+
+	out.Reset()
+	err = Base64DecodeFile(strings.NewReader("aGVs-G8h"), out, makeDecodeMap(charmap), PaddingAuto)
+	if err == nil {
+		t.Errorf("URL safe character decoded with custom alphabet, got %q", out.String())
+	}
+}
+
+func TestValidateAlphabet(t *testing.T) {
+	cases := []string{
+		"ABC",
+		"AACDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/",
+		"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+=",
+		"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+ ",
+	}
+
+	for _, c := range cases {
+		if _, err := LookupAlphabet(c); err == nil {
+			t.Errorf("alphabet %q expected error", c)
+		}
+	}
+}
+
+func TestBase64Padding(t *testing.T) {
+	r := rand.New(rand.NewSource(28))
+	for size := 0; size < 16; size++ {
+		data := make([]byte, size)
+		r.Read(data)
+
+		cases := []struct {
+			charmap []byte
+			pad     bool
+			enc     *base64.Encoding
+		}{
+			{BASE64_ENCODE_STANDARD_MAP, true, base64.StdEncoding},
+			{BASE64_ENCODE_STANDARD_MAP, false, base64.RawStdEncoding},
+			{BASE64_ENCODE_URLSAFE_MAP, true, base64.URLEncoding},
+			{BASE64_ENCODE_URLSAFE_MAP, false, base64.RawURLEncoding},
+		}
+
+		for _, c := range cases {
+			exp := c.enc.EncodeToString(data)
+			out := bytes.NewBuffer(nil)
+			if err := Base64EncodeFile(bytes.NewReader(data), out, c.charmap, c.pad); err != nil {
+				t.Fatalf("encode failed: %s", err)
+			}
+
+			got := strings.TrimSuffix(out.String(), "\n")
+			if got != exp {
+				t.Errorf("encode %x pad=%v got %s; expected %s", data, c.pad, got, exp)
+			}
+
+			policies := []PaddingPolicy{PaddingAuto, PaddingForbidden}
+			if c.pad {
+				policies = []PaddingPolicy{PaddingAuto, PaddingRequired}
+			}
+
+			for _, policy := range policies {
+				out.Reset()
+				err := Base64DecodeFile(strings.NewReader(exp), out, BASE64_DECODE_MAP, policy)
+				if err != nil {
+					t.Fatalf("decode %s with policy %d failed: %s", exp, policy, err)
+				}
+
+				if !bytes.Equal(out.Bytes(), data) {
+					t.Errorf("decode %s got %x; expected %x", exp, out.Bytes(), data)
+				}
+			}
+		}
+	}
+}
+
+func TestBase64PaddingErrors(t *testing.T) {
+	cases := []struct {
+		encoded string
+		policy  PaddingPolicy
+	}{
+		{"QQ", PaddingRequired},
+		{"QUI", PaddingRequired},
+		{"QQ==", PaddingForbidden},
+		{"QUI=", PaddingForbidden},
+		{"QQ=", PaddingAuto},
+		{"Q===", PaddingAuto},
+		{"=QUJD", PaddingAuto},
+		{"QUJD=", PaddingAuto},
+		{"QQ==QUJD", PaddingAuto},
+		{"QUJDR", PaddingAuto},
+	}
+
+	for _, c := range cases {
+		out := bytes.NewBuffer(nil)
+		err := Base64DecodeFile(strings.NewReader(c.encoded), out, BASE64_DECODE_MAP, c.policy)
+		if err == nil {
+			t.Errorf("decode %s with policy %d expected error, got %q", c.encoded, c.policy, out.String())
+		}
+	}
+}
+
+func TestBase64JWT(t *testing.T) {
+	// Sample token from https://jwt.io/
+	token := "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9." +
+		"eyJzdWIiOiIxMjM0NTY3ODkwIiwibmFtZSI6IkpvaG4gRG9lIiwiaWF0IjoxNTE2MjM5MDIyfQ." +
+		"SflKxwRJSMeKKF2QT4fwpMeJf36POk6yJV_adQssw5c"
+
+	cases := []string{
+		`{"alg":"HS256","typ":"JWT"}`,
+		`{"sub":"1234567890","name":"John Doe","iat":1516239022}`,
+		"\x49\xf9\x4a\xc7\x04\x49\x48\xc7\x8a\x28\x5d\x90\x4f\x87\xf0\xa4" +
+			"\xc7\x89\x7f\x7e\x8f\x3a\x4e\xb2\x25\x5f\xda\x75\x0b\x2c\xc3\x97",
+	}
+
+	parts := strings.Split(token, ".")
+	for i, part := range parts {
+		out := bytes.NewBuffer(nil)
+		decodeMap := makeDecodeMap(BASE64_ENCODE_URLSAFE_MAP)
+		if err := Base64DecodeFile(strings.NewReader(part), out, decodeMap, PaddingForbidden); err != nil {
+			t.Fatalf("decode part %d failed: %s", i, err)
+		}
+
+		if out.String() != cases[i] {
+			t.Errorf("part %d got %q; expected %q", i, out.String(), cases[i])
+		}
+
+		encoded := bytes.NewBuffer(nil)
+		if err := Base64EncodeFile(out, encoded, BASE64_ENCODE_URLSAFE_MAP, false); err != nil {
+			t.Fatalf("encode part %d failed: %s", i, err)
+		}
+
+		if got := strings.TrimSuffix(encoded.String(), "\n"); got != part {
+			t.Errorf("part %d got %s; expected %s", i, got, part)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Base64Problem is a problem found by CheckBase64, Line and Column start at 1.
+type Base64Problem struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (p Base64Problem) String() string {
+	return fmt.Sprintf("%d:%d: %s", p.Line, p.Column, p.Message)
+}
+
+type base64Position struct {
+	line   int
+	column int
+}
+
+// CheckBase64 validates the whole input without writing decoded data, and
+// returns every problem found. Mixed alphabets are reported when decodeMap
+// accepts both standard and URL safe characters.
+func CheckBase64(in io.Reader, decodeMap []byte, padding PaddingPolicy) ([]Base64Problem, error) {
+	problems := make([]Base64Problem, 0)
+	reader := bufio.NewReader(in)
+
+	line, column := 1, 0
+	report := func(pos base64Position, format string, args ...interface{}) {
+		problems = append(problems, Base64Problem{pos.line, pos.column, fmt.Sprintf(format, args...)})
+	}
+
+	n := 0
+	pads := 0
+	reportedAfterPadding := false
+	reportedPadding := false
+	var last, firstPad base64Position
+	var lastValue byte
+	var standard, urlsafe *base64Position
+
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return problems, err
+			}
+			break
+		}
+
+		column++
+		pos := base64Position{line, column}
+		if b == '\n' {
+			line++
+			column = 0
+			continue
+		}
+
+		if isSpace(b) {
+			continue
+		}
+
+		if b == '=' {
+			switch {
+			case reportedPadding:
+			case padding == PaddingForbidden:
+				report(pos, "padding is forbidden")
+				reportedPadding = true
+			case n%4 < 2 || n%4+pads >= 4:
+				report(pos, "misplaced padding")
+				reportedPadding = true
+			}
+
+			if pads == 0 {
+				firstPad = pos
+			}
+			pads++
+			continue
+		}
+
+		// invalid characters and padding followed by data still take their
+		// places in groups, so one problem is not reported again at the end
+		v := decodeMap[b]
+		if v == 0xff {
+			if b < 0x20 || b >= 0x7f {
+				report(pos, "invalid character 0x%02x", b)
+			} else {
+				report(pos, "invalid character '%c'", b)
+			}
+			v = 0
+		}
+
+		switch b {
+		case '+', '/':
+			if standard == nil {
+				standard = &base64Position{pos.line, pos.column}
+			}
+		case '-', '_':
+			if urlsafe == nil {
+				urlsafe = &base64Position{pos.line, pos.column}
+			}
+		}
+
+		if pads > 0 {
+			if !reportedAfterPadding {
+				report(pos, "data after padding at %d:%d", firstPad.line, firstPad.column)
+				reportedAfterPadding = true
+			}
+
+			n += pads
+			pads = 0
+		}
+
+		n++
+		last = pos
+		lastValue = v
+	}
+
+	if standard != nil && urlsafe != nil {
+		first, second := standard, urlsafe
+		if second.line < first.line || (second.line == first.line && second.column < first.column) {
+			first, second = second, first
+		}
+
+		report(*second, "mixed standard and URL safe alphabets, first seen at %d:%d", first.line, first.column)
+	}
+
+	r := n % 4
+	switch {
+	case r == 1:
+		report(last, "wrong length, last group has only 1 character")
+
+	case r > 1 && pads > 0 && r+pads != 4 && !reportedPadding:
+		report(firstPad, "incomplete padding, %d '=' for %d characters", pads, r)
+
+	case r > 1 && pads == 0 && padding == PaddingRequired:
+		report(last, "missing padding")
+	}
+
+	if (r == 2 && lastValue&0x0f != 0) || (r == 3 && lastValue&0x03 != 0) {
+		report(last, "non-canonical trailing bits")
+	}
+
+	return problems, nil
+}
+
+// CheckBase64File writes every problem found in in as "name:line:column:
+// message", and returns an error if any problem is found.
+func CheckBase64File(in io.Reader, out io.Writer, name string, decodeMap []byte, padding PaddingPolicy) error {
+	if name == "-" {
+		name = "stdin"
+	}
+
+	problems, err := CheckBase64(in, decodeMap, padding)
+	for _, p := range problems {
+		if _, errWrite := fmt.Fprintf(out, "%s:%s\n", name, p); errWrite != nil {
+			return errWrite
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	switch len(problems) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("%s: 1 problem found", name)
+	default:
+		return fmt.Errorf("%s: %d problems found", name, len(problems))
+	}
+}
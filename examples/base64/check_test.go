@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCheckBase64Valid(t *testing.T) {
+	cases := []struct {
+		text    string
+		padding PaddingPolicy
+	}{
+		{"", PaddingAuto},
+		{"aGVsbG8h\n", PaddingRequired},
+		{"aGVs\nbG8=\n", PaddingRequired},
+		{"aGVsbG8\n", PaddingForbidden},
+		{"  aGVs\r\n  bA==\r\n", PaddingAuto},
+		{"SflKxwRJSMeKKF2QT4fwpMeJf36POk6yJV_adQssw5c", PaddingAuto},
+	}
+
+	for _, c := range cases {
+		problems, err := CheckBase64(strings.NewReader(c.text), BASE64_DECODE_MAP, c.padding)
+		if err != nil {
+			t.Fatalf("check %q failed: %s", c.text, err)
+		}
+
+		if len(problems) > 0 {
+			t.Errorf("check %q got problems %v", c.text, problems)
+		}
+	}
+}
+
+func TestCheckBase64Problems(t *testing.T) {
+	cases := []struct {
+		text    string
+		padding PaddingPolicy
+		exp     []string
+	}{
+		{"aGVs\nbG!h\n", PaddingAuto, []string{"2:3: invalid character '!'"}},
+		{"aGVs\nbG\x01h\n", PaddingAuto, []string{"2:3: invalid character 0x01"}},
+		{"aGVsbG8h=\n", PaddingAuto, []string{"1:9: misplaced padding"}},
+		{"aGVsbA==bG8h\n", PaddingAuto, []string{"1:9: data after padding at 1:7"}},
+		{"aGVsbA=\n", PaddingAuto, []string{"1:7: incomplete padding, 1 '=' for 2 characters"}},
+		{"aGVsbA==", PaddingForbidden, []string{"1:7: padding is forbidden"}},
+		{"aGVsbA", PaddingRequired, []string{"1:6: missing padding"}},
+		{"aGVsb\n", PaddingAuto, []string{"1:5: wrong length, last group has only 1 character"}},
+		{"aGVsbB==", PaddingAuto, []string{"1:6: non-canonical trailing bits"}},
+		{"aGVsbG9=", PaddingAuto, []string{"1:7: non-canonical trailing bits"}},
+		{"ab+c\nab_c\nab/c\n", PaddingAuto, []string{
+			"2:3: mixed standard and URL safe alphabets, first seen at 1:3",
+		}},
+		{"a!Vs\n=GVs\nbGQ", PaddingRequired, []string{
+			"1:2: invalid character '!'",
+			"2:1: misplaced padding",
+			"2:2: data after padding at 2:1",
+			"3:3: missing padding",
+		}},
+	}
+
+	for _, c := range cases {
+		problems, err := CheckBase64(strings.NewReader(c.text), BASE64_DECODE_MAP, c.padding)
+		if err != nil {
+			t.Fatalf("check %q failed: %s", c.text, err)
+		}
+
+		got := make([]string, len(problems))
+		for i, p := range problems {
+			got[i] = p.String()
+		}
+
+		if strings.Join(got, "\n") != strings.Join(c.exp, "\n") {
+			t.Errorf("check %q got:\n%s\nexpected:\n%s", c.text, strings.Join(got, "\n"), strings.Join(c.exp, "\n"))
+		}
+	}
+}
+
+func TestCheckBase64File(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	err := CheckBase64File(strings.NewReader("aGVs\nbG!h\n"), out, "secret.b64", BASE64_DECODE_MAP, PaddingAuto)
+	if err == nil {
+		t.Errorf("expected error")
+	}
+
+	if exp := "secret.b64:2:3: invalid character '!'\n"; out.String() != exp {
+		t.Errorf("got %q; expected %q", out.String(), exp)
+	}
+
+	out.Reset()
+	err = CheckBase64File(strings.NewReader("aGVsbG8h\n"), out, "-", BASE64_DECODE_MAP, PaddingAuto)
+	if err != nil || out.Len() != 0 {
+		t.Errorf("got error %v and output %q", err, out.String())
+	}
+}
+
+func TestCheckOtherEncodings(t *testing.T) {
+	for _, encoding := range []string{"ascii85", "z85", "uu", "xx"} {
+		conf := &Base64Configure{Encoding: encoding, Check: true}
+		if _, err := makeHandler(conf, bytes.NewBuffer(nil)); err == nil {
+			t.Errorf("%s: expected an error of -check", encoding)
+		}
+	}
+}
+
+func TestCheckPerFile(t *testing.T) {
+	conf := &Base64Configure{Encoding: "base64", Check: true, PerFile: true}
+	if _, err := makeHandler(conf, bytes.NewBuffer(nil)); err == nil {
+		t.Errorf("expected an error of -check with -i")
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	EXTRACT_MIN_LENGTH   = 20
+	EXTRACT_PREVIEW_SIZE = 64
+	DATA_URI_PREFIX      = "data:"
+	DATA_URI_BASE64      = ";base64"
+	DATA_URI_MAX_HEADER  = 256
+)
+
+// Base64Fragment is a decodable base64 run found in text.
+type Base64Fragment struct {
+	Offset   int
+	Length   int
+	Alphabet string
+	MIME     string
+	Data     []byte
+}
+
+func isBase64Char(c byte) bool {
+	return BASE64_DECODE_MAP[c] != 0xff
+}
+
+// detectAlphabet returns the alphabet name of run, "any" if run only has
+// characters shared by standard and URL safe alphabets, and "" if it mixes
+// characters of both.
+func detectAlphabet(run []byte) string {
+	standard := bytes.ContainsAny(run, "+/")
+	urlsafe := bytes.ContainsAny(run, "-_")
+	switch {
+	case standard && urlsafe:
+		return ""
+	case standard:
+		return "standard"
+	case urlsafe:
+		return "url"
+	default:
+		return "any"
+	}
+}
+
+// looksLikeBase64 filters out words, identifiers and paths, which are valid
+// base64 characters but rarely contain both upper and lower case letters.
+func looksLikeBase64(run []byte) bool {
+	upper, lower := false, false
+	for _, c := range run {
+		upper = upper || (c >= 'A' && c <= 'Z')
+		lower = lower || (c >= 'a' && c <= 'z')
+	}
+
+	return upper && lower
+}
+
+func decodeFragment(run []byte, alphabet string) ([]byte, error) {
+	decodeMap := BASE64_DECODE_MAP
+	switch alphabet {
+	case "standard":
+		decodeMap = makeDecodeMap(BASE64_ENCODE_STANDARD_MAP)
+	case "url":
+		decodeMap = makeDecodeMap(BASE64_ENCODE_URLSAFE_MAP)
+	}
+
+	out := bytes.NewBuffer(nil)
+	err := Base64DecodeFile(bytes.NewReader(run), out, decodeMap, PaddingAuto)
+	return out.Bytes(), err
+}
+
+// parseDataURI returns the MIME type of a "data:<mime>;base64," header at the
+// beginning of text, and the offset of data after it.
+func parseDataURI(text []byte) (string, int, bool) {
+	if !bytes.HasPrefix(text, []byte(DATA_URI_PREFIX)) {
+		return "", 0, false
+	}
+
+	limit := len(text)
+	if limit > DATA_URI_MAX_HEADER {
+		limit = DATA_URI_MAX_HEADER
+	}
+
+	comma := bytes.IndexByte(text[:limit], ',')
+	if comma < 0 {
+		return "", 0, false
+	}
+
+	header := string(text[len(DATA_URI_PREFIX):comma])
+	if strings.ContainsAny(header, " \t\r\n\"'") || !strings.HasSuffix(header, DATA_URI_BASE64) {
+		return "", 0, false
+	}
+
+	mime := header[:strings.IndexByte(header, ';')]
+	if mime == "" {
+		mime = "text/plain"
+	}
+
+	return mime, comma + 1, true
+}
+
+func scanRun(text []byte, start int) int {
+	end := start
+	for end < len(text) && isBase64Char(text[end]) {
+		end++
+	}
+
+	for pad := 0; pad < 2 && end < len(text) && text[end] == '='; pad++ {
+		end++
+	}
+
+	return end
+}
+
+// FindBase64Fragments scans text for base64 runs of at least minLength
+// characters and base64 data URIs of any length, and returns the fragments
+// which can be decoded.
+func FindBase64Fragments(text []byte, minLength int) []Base64Fragment {
+	fragments := make([]Base64Fragment, 0)
+
+	i := 0
+	for i < len(text) {
+		if !isBase64Char(text[i]) || (i > 0 && isBase64Char(text[i-1])) {
+			i++
+			continue
+		}
+
+		mime, offset, isDataURI := parseDataURI(text[i:])
+		start := i
+		if isDataURI {
+			start = i + offset
+		}
+
+		end := scanRun(text, start)
+		i = end
+		if end == start {
+			continue
+		}
+
+		run := text[start:end]
+		if !isDataURI && (len(bytes.TrimRight(run, "=")) < minLength || !looksLikeBase64(run)) {
+			continue
+		}
+
+		alphabet := detectAlphabet(run)
+		if alphabet == "" {
+			continue
+		}
+
+		data, err := decodeFragment(run, alphabet)
+		if err != nil {
+			continue
+		}
+
+		fragments = append(fragments, Base64Fragment{
+			Offset:   start,
+			Length:   len(run),
+			Alphabet: alphabet,
+			MIME:     mime,
+			Data:     data,
+		})
+	}
+
+	return fragments
+}
+
+// writePreview writes up to limit bytes of data as lines of offset, hex and
+// printable ASCII.
+func writePreview(out io.Writer, data []byte, limit int) error {
+	if len(data) > limit {
+		data = data[:limit]
+	}
+
+	for i := 0; i < len(data); i += 16 {
+		line := data[i:]
+		if len(line) > 16 {
+			line = line[:16]
+		}
+
+		ascii := make([]byte, len(line))
+		for j, c := range line {
+			ascii[j] = '.'
+			if c >= 0x20 && c < 0x7f {
+				ascii[j] = c
+			}
+		}
+
+		_, err := fmt.Fprintf(out, "  %08x  %-47s  |%s|\n", i, fmt.Sprintf("% x", line), ascii)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExtractBase64File reports every base64 fragment found in in, and writes
+// the decoded data of each fragment to a file in dir if dir is not empty.
+func ExtractBase64File(in io.Reader, out io.Writer, name string, minLength int, dir string) error {
+	text, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+
+	if name == "-" {
+		name = "stdin"
+	}
+
+	for _, f := range FindBase64Fragments(text, minLength) {
+		_, err := fmt.Fprintf(out, "%s: offset %d, length %d, alphabet %s", name, f.Offset, f.Length, f.Alphabet)
+		if err != nil {
+			return err
+		}
+
+		if f.MIME != "" {
+			if _, err := fmt.Fprintf(out, ", data URI %s", f.MIME); err != nil {
+				return err
+			}
+		}
+
+		if dir != "" {
+			filename := filepath.Join(dir, fmt.Sprintf("%s.%d.bin", filepath.Base(name), f.Offset))
+			if err := os.WriteFile(filename, f.Data, 0644); err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintf(out, ", %d bytes written to %s\n", len(f.Data), filename)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := fmt.Fprintf(out, ", %d bytes\n", len(f.Data)); err != nil {
+			return err
+		}
+
+		if err := writePreview(out, f.Data, EXTRACT_PREVIEW_SIZE); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindBase64Fragments(t *testing.T) {
+	text := `2024-01-01T00:00:00Z INFO request path=/usr/local/share/something-long-name ` +
+		`body={"token":"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9","short":"aGk=",` +
+		`"icon":"data:image/png;base64,iVBORw0KGgo=","sig":"SflKxwRJSMeKKF2QT4fwpMeJf36POk6yJV_adQssw5c",` +
+		`"std":"SGVsbG8sIFdvcmxkISBIZWxsbyE/Pz8+Pj4="}`
+
+	exp := []struct {
+		alphabet string
+		mime     string
+		data     string
+	}{
+		{"any", "", `{"alg":"HS256","typ":"JWT"}`},
+		{"any", "image/png", "\x89PNG\r\n\x1a\n"},
+		{"url", "", "\x49\xf9\x4a\xc7\x04\x49\x48\xc7\x8a\x28\x5d\x90\x4f\x87\xf0\xa4" +
+			"\xc7\x89\x7f\x7e\x8f\x3a\x4e\xb2\x25\x5f\xda\x75\x0b\x2c\xc3\x97"},
+		{"standard", "", "Hello, World! Hello!???>>>"},
+	}
+
+	fragments := FindBase64Fragments([]byte(text), EXTRACT_MIN_LENGTH)
+	if len(fragments) != len(exp) {
+		t.Fatalf("got %d fragments; expected %d: %+v", len(fragments), len(exp), fragments)
+	}
+
+	for i, f := range fragments {
+		if f.Alphabet != exp[i].alphabet || f.MIME != exp[i].mime || string(f.Data) != exp[i].data {
+			t.Errorf("fragment %d got %s %s %q; expected %s %s %q", i,
+				f.Alphabet, f.MIME, f.Data, exp[i].alphabet, exp[i].mime, exp[i].data)
+		}
+
+		run := text[f.Offset : f.Offset+f.Length]
+		if strings.ContainsAny(run, `":,`) {
+			t.Errorf("fragment %d has wrong boundary: %s", i, run)
+		}
+	}
+}
+
+func TestExtractBase64File(t *testing.T) {
+	dir := t.TempDir()
+	text := "key=SGVsbG8sIFdvcmxkISBIZWxsbyE=\n"
+
+	out := bytes.NewBuffer(nil)
+	if err := ExtractBase64File(strings.NewReader(text), out, "app.log", EXTRACT_MIN_LENGTH, ""); err != nil {
+		t.Fatalf("extract failed: %s", err)
+	}
+
+	exp := "app.log: offset 4, length 28, alphabet any, 20 bytes\n" +
+		"  00000000  48 65 6c 6c 6f 2c 20 57 6f 72 6c 64 21 20 48 65  |Hello, World! He|\n" +
+		"  00000010  6c 6c 6f 21                                      |llo!|\n"
+	if out.String() != exp {
+		t.Errorf("got:\n%s\nexpected:\n%s", out.String(), exp)
+	}
+
+	out.Reset()
+	if err := ExtractBase64File(strings.NewReader(text), out, "logs/app.log", EXTRACT_MIN_LENGTH, dir); err != nil {
+		t.Fatalf("extract to dir failed: %s", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "app.log.4.bin"))
+	if err != nil {
+		t.Fatalf("read extracted file failed: %s", err)
+	}
+
+	if string(got) != "Hello, World! Hello!" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestExtractPerFile(t *testing.T) {
+	conf := &Base64Configure{Encoding: "base64", Extract: true, PerFile: true}
+	if _, err := makeHandler(conf, bytes.NewBuffer(nil)); err == nil {
+		t.Errorf("expected an error of -extract with -i")
+	}
+}
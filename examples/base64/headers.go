@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	BASE64_HEADER_BEGIN = "begin-base64"
+	BASE64_HEADER_END   = "===="
+	BASE64_HEADER_WIDTH = 76
+)
+
+// ENCODING_SUFFIXES are the file name suffixes of per-file output.
+var ENCODING_SUFFIXES = map[string]string{
+	"base64":  ".b64",
+	"ascii85": ".a85",
+	"z85":     ".z85",
+	"uu":      ".uu",
+	"xx":      ".xx",
+}
+
+// PerFileOutputName returns the output file name of filename in per-file
+// mode, which appends the suffix of encoding, or strips it when decoding.
+func PerFileOutputName(filename string, encoding string, decode bool) (string, error) {
+	if filename == "-" {
+		return "", errors.New("standard input can not be written to a per-file output")
+	}
+
+	suffix, found := ENCODING_SUFFIXES[encoding]
+	if !found {
+		return "", fmt.Errorf("unknown encoding '%s'", encoding)
+	}
+
+	if !decode {
+		return filename + suffix, nil
+	}
+
+	if !strings.HasSuffix(filename, suffix) || len(filename) == len(suffix) {
+		return "", fmt.Errorf("file name '%s' does not end with '%s'", filename, suffix)
+	}
+
+	return strings.TrimSuffix(filename, suffix), nil
+}
+
+// Base64EncodeWithHeader encodes in as a "begin-base64" block as written by
+// uuencode -m, so multiple files can be carried by one stream.
+func Base64EncodeWithHeader(in io.Reader, out io.Writer, header UUHeader) error {
+	begin := fmt.Sprintf("%s %03o %s", BASE64_HEADER_BEGIN, header.Mode.Perm(), header.Name)
+	return base64EncodeFramed(in, out, begin, BASE64_HEADER_END, BASE64_HEADER_WIDTH, "\n")
+}
+
+// Base64DecodeWithHeaders decodes every "begin-base64" block in in. The
+// output goes to out, or to the file named in each header if toFile is true.
+func Base64DecodeWithHeaders(in io.Reader, out io.Writer, toFile bool) error {
+	reader := bufio.NewReader(in)
+	body := bytes.NewBuffer(nil)
+	found := 0
+
+	var header *UUHeader
+	for {
+		line, err := readLine(reader)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return err
+			}
+			break
+		}
+
+		if header == nil {
+			header, _ = parseUUHeader(line, BASE64_HEADER_BEGIN)
+			body.Reset()
+			continue
+		}
+
+		if line != BASE64_HEADER_END {
+			body.WriteString(line)
+			continue
+		}
+
+		decode := func(w io.Writer) error {
+			return Base64DecodeFile(body, w, BASE64_DECODE_MAP, PaddingAuto)
+		}
+
+		if toFile {
+			err = decodeToHeaderFile(header, decode)
+		} else {
+			err = decode(out)
+		}
+
+		if err != nil {
+			return fmt.Errorf("file '%s': %w", header.Name, err)
+		}
+
+		found++
+		header = nil
+	}
+
+	if header != nil {
+		return fmt.Errorf("file '%s': missing '%s' line", header.Name, BASE64_HEADER_END)
+	}
+
+	if found == 0 {
+		return fmt.Errorf("no '%s' line found", BASE64_HEADER_BEGIN)
+	}
+
+	return nil
+}
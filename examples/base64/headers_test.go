@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPerFileOutputName(t *testing.T) {
+	cases := []struct {
+		filename string
+		encoding string
+		decode   bool
+		exp      string
+	}{
+		{"a.txt", "base64", false, "a.txt.b64"},
+		{"dir/a.txt.b64", "base64", true, "dir/a.txt"},
+		{"a.bin", "ascii85", false, "a.bin.a85"},
+		{"a.bin.uu", "uu", true, "a.bin"},
+	}
+
+	for _, c := range cases {
+		got, err := PerFileOutputName(c.filename, c.encoding, c.decode)
+		if err != nil {
+			t.Fatalf("output name of '%s' failed: %s", c.filename, err)
+		}
+
+		if got != c.exp {
+			t.Errorf("output name of '%s' got '%s'; expected '%s'", c.filename, got, c.exp)
+		}
+	}
+
+	errorCases := []struct {
+		filename string
+		encoding string
+		decode   bool
+	}{
+		{"-", "base64", false},
+		{"a.txt", "base64", true},
+		{".b64", "base64", true},
+		{"a.txt", "base32", false},
+	}
+
+	for _, c := range errorCases {
+		if _, err := PerFileOutputName(c.filename, c.encoding, c.decode); err == nil {
+			t.Errorf("output name of '%s' expected error", c.filename)
+		}
+	}
+}
+
+func TestBase64Headers(t *testing.T) {
+	files := []struct {
+		header UUHeader
+		data   string
+	}{
+		{UUHeader{0644, "a.txt"}, "aaaa"},
+		{UUHeader{0600, "b.txt"}, strings.Repeat("b", 100)},
+		{UUHeader{0755, "empty"}, ""},
+	}
+
+	stream := bytes.NewBufferString("leading text\n")
+	all := ""
+	for _, f := range files {
+		if err := Base64EncodeWithHeader(strings.NewReader(f.data), stream, f.header); err != nil {
+			t.Fatalf("encode '%s' failed: %s", f.header.Name, err)
+		}
+		all += f.data
+	}
+
+	if !strings.Contains(stream.String(), "begin-base64 600 b.txt\n") {
+		t.Errorf("header of b.txt not found in:\n%s", stream.String())
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := Base64DecodeWithHeaders(bytes.NewReader(stream.Bytes()), out, false); err != nil {
+		t.Fatalf("decode failed: %s", err)
+	}
+
+	if out.String() != all {
+		t.Errorf("got %q; expected %q", out.String(), all)
+	}
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	if err := Base64DecodeWithHeaders(stream, nil, true); err != nil {
+		t.Fatalf("decode to files failed: %s", err)
+	}
+
+	for _, f := range files {
+		filename := filepath.Join(dir, f.header.Name)
+		got, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatalf("read '%s' failed: %s", filename, err)
+		}
+
+		if string(got) != f.data {
+			t.Errorf("file '%s' got %q; expected %q", f.header.Name, got, f.data)
+		}
+
+		info, err := os.Stat(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if info.Mode().Perm() != f.header.Mode {
+			t.Errorf("file '%s' got mode %03o; expected %03o", f.header.Name, info.Mode().Perm(), f.header.Mode)
+		}
+	}
+}
+
+func TestBase64HeadersErrors(t *testing.T) {
+	cases := []string{
+		"no header\n",
+		"begin-base64 644 a\nYWFh\n",
+		"begin-base64 644 a\nYW!h\n====\n",
+	}
+
+	for _, c := range cases {
+		out := bytes.NewBuffer(nil)
+		if err := Base64DecodeWithHeaders(strings.NewReader(c), out, false); err == nil {
+			t.Errorf("decode %q expected error", c)
+		}
+	}
+}
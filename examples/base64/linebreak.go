@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+)
+
+// LineBreakWriter breaks lines every width characters, and writes each line
+// with prefix and eol. A '\n' in the written data also ends a line, so the
+// line break due at width is dropped if the data ends the line by itself.
+type LineBreakWriter struct {
+	writer  *bufio.Writer
+	file    *os.File
+	count   int
+	width   int
+	eol     string
+	prefix  string
+	pending bool
+}
+
+func NewLineBreakWriter(writer io.Writer, width int) *LineBreakWriter {
+	w := &LineBreakWriter{
+		writer: bufio.NewWriter(writer),
+		count:  0,
+		width:  width,
+		eol:    "\n",
+	}
+
+	return w
+}
+
+// NewLineBreakFileWriter creates filename and returns a writer owning it,
+// the file is closed by Close.
+func NewLineBreakFileWriter(filename string, width int) (*LineBreakWriter, error) {
+	w := NewLineBreakWriter(io.Discard, width)
+	if err := w.ToFile(filename); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// SetLineEnding sets the line ending, which replaces '\n' in written data.
+func (w *LineBreakWriter) SetLineEnding(eol string) {
+	w.eol = eol
+}
+
+// SetPrefix sets the prefix written before the first character of each line.
+func (w *LineBreakWriter) SetPrefix(prefix string) {
+	w.prefix = prefix
+}
+
+// ToFile flushes buffered data, closes the file owned by w if any, and
+// redirects the output to a newly created filename.
+func (w *LineBreakWriter) ToFile(filename string) error {
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	w.file = file
+	w.writer = bufio.NewWriter(file)
+	w.count = 0
+	w.pending = false
+	return nil
+}
+
+func (w *LineBreakWriter) endLine() error {
+	w.count = 0
+	w.pending = false
+	_, err := w.writer.WriteString(w.eol)
+	return err
+}
+
+func (w *LineBreakWriter) Write(data []byte) (int, error) {
+	if w.width <= 0 && w.eol == "\n" && w.prefix == "" {
+		return w.writer.Write(data)
+	}
+
+	c := 0
+	for len(data) > 0 {
+		if data[0] == '\n' {
+			if err := w.endLine(); err != nil {
+				return c, err
+			}
+
+			data = data[1:]
+			c += 1
+			continue
+		}
+
+		if w.pending {
+			if err := w.endLine(); err != nil {
+				return c, err
+			}
+		}
+
+		if w.count == 0 && w.prefix != "" {
+			if _, err := w.writer.WriteString(w.prefix); err != nil {
+				return c, err
+			}
+		}
+
+		// write up to the next line break in one call
+		segment := data
+		if w.width > 0 && len(segment) > w.width-w.count {
+			segment = segment[:w.width-w.count]
+		}
+
+		if i := bytes.IndexByte(segment, '\n'); i >= 0 {
+			segment = segment[:i]
+		}
+
+		n, err := w.writer.Write(segment)
+		c += n
+		w.count += n
+		if err != nil {
+			return c, err
+		}
+
+		data = data[n:]
+		if w.width > 0 && w.count >= w.width {
+			w.pending = true
+		}
+	}
+
+	return c, nil
+}
+
+func (w *LineBreakWriter) Flush() error {
+	return w.writer.Flush()
+}
+
+// Close terminates the last line if it is broken at width, flushes buffered
+// data and closes the file owned by w. The writer passed to
+// NewLineBreakWriter is not closed.
+func (w *LineBreakWriter) Close() error {
+	var err error
+	if w.pending {
+		err = w.endLine()
+	}
+
+	if errFlush := w.writer.Flush(); err == nil {
+		err = errFlush
+	}
+
+	if w.file != nil {
+		if errClose := w.file.Close(); err == nil {
+			err = errClose
+		}
+		w.file = nil
+	}
+
+	return err
+}
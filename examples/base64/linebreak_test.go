@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLineBreakWriterMultipleFiles(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	out := NewLineBreakWriter(buf, 8)
+	for _, s := range []string{"abc", "abcdefghijklmn"} {
+		if err := Base64EncodeFile(strings.NewReader(s), out, BASE64_ENCODE_STANDARD_MAP, true); err != nil {
+			t.Fatalf("encode failed: %s", err)
+		}
+	}
+
+	if err := out.Close(); err != nil {
+		t.Fatalf("close failed: %s", err)
+	}
+
+	exp := "YWJj\nYWJjZGVm\nZ2hpamts\nbW4=\n"
+	if buf.String() != exp {
+		t.Errorf("got %q; expected %q", buf.String(), exp)
+	}
+}
+
+func TestLineBreakWriterOptions(t *testing.T) {
+	cases := []struct {
+		width  int
+		eol    string
+		prefix string
+		writes []string
+		exp    string
+	}{
+		{4, "\n", "", []string{"abcd", "\n"}, "abcd\n"},
+		{4, "\n", "", []string{"abcdefgh\n"}, "abcd\nefgh\n"},
+		{4, "\n", "", []string{"abcdefgh"}, "abcd\nefgh\n"},
+		{4, "\n", "", []string{"ab", "cdef", "gh", "\n\n"}, "abcd\nefgh\n\n"},
+		{4, "\r\n", "", []string{"abcdef\n"}, "abcd\r\nef\r\n"},
+		{4, "\n", "  ", []string{"abcdef\n", "gh\n"}, "  abcd\n  ef\n  gh\n"},
+		{0, "\r\n", "> ", []string{"abc\ndef\n"}, "> abc\r\n> def\r\n"},
+		{0, "\n", "", []string{"a\nb"}, "a\nb"},
+	}
+
+	for i, c := range cases {
+		buf := bytes.NewBuffer(nil)
+		out := NewLineBreakWriter(buf, c.width)
+		out.SetLineEnding(c.eol)
+		out.SetPrefix(c.prefix)
+
+		for _, s := range c.writes {
+			n, err := out.Write([]byte(s))
+			if err != nil {
+				t.Fatalf("case %d: write failed: %s", i, err)
+			}
+
+			if n != len(s) {
+				t.Errorf("case %d: wrote %d; expected %d", i, n, len(s))
+			}
+		}
+
+		if err := out.Close(); err != nil {
+			t.Fatalf("case %d: close failed: %s", i, err)
+		}
+
+		if buf.String() != c.exp {
+			t.Errorf("case %d: got %q; expected %q", i, buf.String(), c.exp)
+		}
+	}
+}
+
+type failingWriter struct {
+	limit int
+}
+
+func (w *failingWriter) Write(data []byte) (int, error) {
+	if len(data) > w.limit {
+		n := w.limit
+		w.limit = 0
+		return n, errors.New("disk full")
+	}
+
+	w.limit -= len(data)
+	return len(data), nil
+}
+
+func TestLineBreakWriterErrors(t *testing.T) {
+	out := NewLineBreakWriter(&failingWriter{limit: 10}, 4)
+	data := bytes.Repeat([]byte("a"), 8192)
+
+	_, err := out.Write(data)
+	if err == nil {
+		err = out.Close()
+	}
+
+	if err == nil {
+		t.Errorf("expected write error")
+	}
+}
+
+func TestLineBreakWriterFiles(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first")
+	second := filepath.Join(dir, "second")
+
+	out, err := NewLineBreakFileWriter(first, 4)
+	if err != nil {
+		t.Fatalf("create failed: %s", err)
+	}
+
+	if _, err := out.Write([]byte("abcd")); err != nil {
+		t.Fatalf("write failed: %s", err)
+	}
+
+	if err := out.ToFile(second); err != nil {
+		t.Fatalf("switch file failed: %s", err)
+	}
+
+	if _, err := out.Write([]byte("efg\n")); err != nil {
+		t.Fatalf("write failed: %s", err)
+	}
+
+	if err := out.Close(); err != nil {
+		t.Fatalf("close failed: %s", err)
+	}
+
+	if err := out.Close(); err != nil {
+		t.Fatalf("close twice failed: %s", err)
+	}
+
+	for filename, exp := range map[string]string{first: "abcd\n", second: "efg\n"} {
+		got, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatalf("read failed: %s", err)
+		}
+
+		if string(got) != exp {
+			t.Errorf("file %s got %q; expected %q", filename, got, exp)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+)
+
+const (
+	// PARALLEL_CHUNK_SIZE is the input size of each encoding job, a multiple
+	// of 3 so that only the last chunk is padded.
+	PARALLEL_CHUNK_SIZE = 3 * 256 * 1024
+
+	// PARALLEL_DECODE_CHUNK_SIZE is the number of characters of each decoding
+	// job, a multiple of 4.
+	PARALLEL_DECODE_CHUNK_SIZE = PARALLEL_CHUNK_SIZE / 3 * 4
+)
+
+type chunkResult struct {
+	data []byte
+	err  error
+}
+
+// parallelWrite calls work on every chunk returned by next in up to workers
+// goroutines, and writes the results to out in the order of the chunks. next
+// returns io.EOF after the last chunk.
+func parallelWrite(out io.Writer, workers int, next func() ([]byte, error), work func([]byte) ([]byte, error)) error {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	queue := make(chan chan chunkResult, workers)
+	done := make(chan struct{})
+	var errNext error
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(queue)
+
+		for {
+			src, err := next()
+			if len(src) > 0 {
+				result := make(chan chunkResult, 1)
+				select {
+				case queue <- result:
+				case <-done:
+					return
+				}
+
+				go func() {
+					data, err := work(src)
+					result <- chunkResult{data, err}
+				}()
+			}
+
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					errNext = err
+				}
+				return
+			}
+		}
+	}()
+
+	var err error
+	for result := range queue {
+		r := <-result
+		if err != nil {
+			continue
+		}
+
+		err = r.err
+		if err == nil {
+			_, err = out.Write(r.data)
+		}
+
+		if err != nil {
+			close(done)
+		}
+	}
+
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+
+	return errNext
+}
+
+// Base64EncodeParallel produces the same output as Base64EncodeFile, with
+// chunks of input encoded concurrently by workers goroutines, 0 means the
+// number of CPUs.
+func Base64EncodeParallel(in io.Reader, out io.Writer, charmap []byte, pad bool, workers int) error {
+	reader := bufio.NewReaderSize(in, PARALLEL_CHUNK_SIZE)
+	next := func() ([]byte, error) {
+		buf := make([]byte, PARALLEL_CHUNK_SIZE)
+		n, err := io.ReadFull(reader, buf)
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			err = io.EOF
+		}
+
+		return buf[:n], err
+	}
+
+	work := func(src []byte) ([]byte, error) {
+		dst := make([]byte, (len(src)+2)/3*4)
+		n := base64EncodeBlock(dst, src, charmap, pad)
+		return dst[:n], nil
+	}
+
+	if err := parallelWrite(out, workers, next, work); err != nil {
+		return err
+	}
+
+	_, err := out.Write([]byte("\n"))
+	return err
+}
+
+// base64DecodeBlock decodes src of full 4-character groups without padding
+// or whitespace into dst.
+func base64DecodeBlock(dst []byte, src []byte, decodeMap []byte) (int, error) {
+	j := 0
+	for i := 0; i+4 <= len(src); i += 4 {
+		a, b := decodeMap[src[i+0]], decodeMap[src[i+1]]
+		c, d := decodeMap[src[i+2]], decodeMap[src[i+3]]
+		if (a|b|c|d)&0xc0 != 0 {
+			for k := 0; k < 4; k++ {
+				if ch := src[i+k]; ch == '=' {
+					return j, errors.New("misplaced base64 padding")
+				} else if decodeMap[ch] == 0xff {
+					return j, fmt.Errorf("invalid base64 character '%c'", ch)
+				}
+			}
+		}
+
+		dst[j+0] = (a << 2) | (b >> 4)
+		dst[j+1] = (b << 4) | (c >> 2)
+		dst[j+2] = (c << 6) | d
+		j += 3
+	}
+
+	return j, nil
+}
+
+// Base64DecodeParallel produces the same output as Base64DecodeFile, with
+// full groups decoded concurrently by workers goroutines, 0 means the number
+// of CPUs. The last chunk is decoded by Base64DecodeFile to apply padding.
+func Base64DecodeParallel(in io.Reader, out io.Writer, decodeMap []byte, padding PaddingPolicy, workers int) error {
+	reader := bufio.NewReaderSize(in, PARALLEL_DECODE_CHUNK_SIZE)
+	raw := make([]byte, PARALLEL_DECODE_CHUNK_SIZE)
+	acc := make([]byte, 0, 2*PARALLEL_DECODE_CHUNK_SIZE)
+
+	// a chunk is only sent to workers when another chunk follows it, so any
+	// padding is left in acc for the final decoding.
+	next := func() ([]byte, error) {
+		for len(acc) < 2*PARALLEL_DECODE_CHUNK_SIZE {
+			n, err := reader.Read(raw)
+			for _, b := range raw[:n] {
+				if !isSpace(b) {
+					acc = append(acc, b)
+				}
+			}
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		chunk := acc[:PARALLEL_DECODE_CHUNK_SIZE]
+		rest := make([]byte, len(acc)-len(chunk), 2*PARALLEL_DECODE_CHUNK_SIZE)
+		copy(rest, acc[len(chunk):])
+		acc = rest
+		return chunk, nil
+	}
+
+	work := func(src []byte) ([]byte, error) {
+		dst := make([]byte, len(src)/4*3)
+		n, err := base64DecodeBlock(dst, src, decodeMap)
+		return dst[:n], err
+	}
+
+	if err := parallelWrite(out, workers, next, work); err != nil {
+		return err
+	}
+
+	return Base64DecodeFile(bytes.NewReader(acc), out, decodeMap, padding)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func encodeWrapped(t testing.TB, data []byte, width int, workers int) []byte {
+	buf := bytes.NewBuffer(nil)
+	out := NewLineBreakWriter(buf, width)
+
+	var err error
+	if workers == 1 {
+		err = Base64EncodeFile(bytes.NewReader(data), out, BASE64_ENCODE_STANDARD_MAP, true)
+	} else {
+		err = Base64EncodeParallel(bytes.NewReader(data), out, BASE64_ENCODE_STANDARD_MAP, true, workers)
+	}
+
+	if err != nil {
+		t.Fatalf("encode failed: %s", err)
+	}
+
+	_ = out.Close()
+	return buf.Bytes()
+}
+
+func TestBase64Parallel(t *testing.T) {
+	r := rand.New(rand.NewSource(32))
+	sizes := []int{
+		0, 1, 2, 3,
+		PARALLEL_CHUNK_SIZE - 1,
+		PARALLEL_CHUNK_SIZE,
+		PARALLEL_CHUNK_SIZE + 1,
+		2*PARALLEL_CHUNK_SIZE - 1,
+		5*PARALLEL_CHUNK_SIZE + 2,
+	}
+
+	for _, size := range sizes {
+		data := make([]byte, size)
+		r.Read(data)
+
+		for _, width := range []int{0, 64, 76} {
+			exp := encodeWrapped(t, data, width, 1)
+			got := encodeWrapped(t, data, width, 4)
+			if !bytes.Equal(got, exp) {
+				t.Fatalf("encode %d bytes with width %d mismatch", size, width)
+			}
+
+			decoded := bytes.NewBuffer(nil)
+			err := Base64DecodeParallel(bytes.NewReader(got), decoded, BASE64_DECODE_MAP, PaddingRequired, 4)
+			if err != nil {
+				t.Fatalf("decode %d bytes with width %d failed: %s", size, width, err)
+			}
+
+			if !bytes.Equal(decoded.Bytes(), data) {
+				t.Fatalf("decode %d bytes with width %d mismatch", size, width)
+			}
+		}
+	}
+}
+
+func TestBase64DecodeParallelErrors(t *testing.T) {
+	data := make([]byte, 3*PARALLEL_CHUNK_SIZE)
+	encoded := encodeWrapped(t, data, 76, 4)
+
+	cases := []struct {
+		offset int
+		c      byte
+	}{
+		{10, '!'},
+		{len(encoded) / 2, '='},
+		{len(encoded) - 10, '*'},
+	}
+
+	for _, c := range cases {
+		broken := append([]byte{}, encoded...)
+		broken[c.offset] = c.c
+
+		err := Base64DecodeParallel(bytes.NewReader(broken), io.Discard, BASE64_DECODE_MAP, PaddingAuto, 4)
+		if err == nil {
+			t.Errorf("decode with '%c' at %d expected error", c.c, c.offset)
+		}
+	}
+}
+
+func benchmarkBase64Encode(b *testing.B, workers int) {
+	data := make([]byte, 256*1024*1024)
+	rand.New(rand.NewSource(32)).Read(data)
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		out := NewLineBreakWriter(io.Discard, 76)
+		var err error
+		if workers == 1 {
+			err = Base64EncodeFile(bytes.NewReader(data), out, BASE64_ENCODE_STANDARD_MAP, true)
+		} else {
+			err = Base64EncodeParallel(bytes.NewReader(data), out, BASE64_ENCODE_STANDARD_MAP, true, workers)
+		}
+
+		if err != nil {
+			b.Fatal(err)
+		}
+		_ = out.Close()
+	}
+}
+
+func benchmarkBase64Decode(b *testing.B, workers int) {
+	data := make([]byte, 256*1024*1024)
+	rand.New(rand.NewSource(32)).Read(data)
+	encoded := encodeWrapped(b, data, 76, 0)
+
+	b.SetBytes(int64(len(encoded)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var err error
+		if workers == 1 {
+			err = Base64DecodeFile(bytes.NewReader(encoded), io.Discard, BASE64_DECODE_MAP, PaddingAuto)
+		} else {
+			err = Base64DecodeParallel(bytes.NewReader(encoded), io.Discard, BASE64_DECODE_MAP, PaddingAuto, workers)
+		}
+
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBase64Encode(b *testing.B) {
+	b.Run("sequential", func(bb *testing.B) { benchmarkBase64Encode(bb, 1) })
+	b.Run("parallel", func(bb *testing.B) { benchmarkBase64Encode(bb, 0) })
+}
+
+func BenchmarkBase64Decode(b *testing.B) {
+	b.Run("sequential", func(bb *testing.B) { benchmarkBase64Decode(bb, 1) })
+	b.Run("parallel", func(bb *testing.B) { benchmarkBase64Decode(bb, 0) })
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	PEM_LINE_WIDTH  = 64
+	PEM_BEGIN       = "-----BEGIN "
+	PEM_END         = "-----END "
+	PEM_DASHES      = "-----"
+	PEM_LABEL_MATCH = "*"
+)
+
+// base64EncodeBlock encodes src into dst, which must have enough room for
+// the encoded data, and returns the number of characters written.
+func base64EncodeBlock(dst []byte, src []byte, charmap []byte, pad bool) int {
+	j := 0
+	for i := 0; i < len(src); i += 3 {
+		var b1, b2 byte
+		n := len(src) - i
+		if n > 1 {
+			b1 = src[i+1]
+		}
+		if n > 2 {
+			b2 = src[i+2]
+		}
+
+		dst[j+0] = charmap[(src[i]&0xfc)>>2]
+		dst[j+1] = charmap[((src[i]&0x03)<<4)|((b1&0xf0)>>4)]
+		dst[j+2] = charmap[((b1&0x0f)<<2)|((b2&0xc0)>>6)]
+		dst[j+3] = charmap[(b2&0x3f)>>0]
+
+		if n >= 3 {
+			j += 4
+			continue
+		}
+
+		dst[j+3] = '='
+		if n < 2 {
+			dst[j+2] = '='
+		}
+
+		if pad {
+			j += 4
+		} else {
+			j += n + 1
+		}
+	}
+
+	return j
+}
+
+// base64EncodeFramed encodes in between the begin and end lines, with lines
+// of width characters terminated by eol.
+func base64EncodeFramed(in io.Reader, out io.Writer, begin string, end string, width int, eol string) error {
+	reader := bufio.NewReader(in)
+	buf := make([]byte, width/4*3)
+	line := make([]byte, width, width+len(eol))
+
+	_, err := io.WriteString(out, begin+eol)
+	if err != nil {
+		return err
+	}
+
+	for {
+		n, err := io.ReadFull(reader, buf)
+		if n == 0 {
+			if err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+			break
+		}
+
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+
+		size := base64EncodeBlock(line, buf[:n], BASE64_ENCODE_STANDARD_MAP, true)
+		_, err = out.Write(append(line[:size], eol...))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = io.WriteString(out, end+eol)
+	return err
+}
+
+// PemEncodeFile encodes in as a single PEM block labeled label, with lines of
+// 64 characters terminated by eol.
+func PemEncodeFile(in io.Reader, out io.Writer, label string, eol string) error {
+	begin := PEM_BEGIN + label + PEM_DASHES
+	end := PEM_END + label + PEM_DASHES
+	return base64EncodeFramed(in, out, begin, end, PEM_LINE_WIDTH, eol)
+}
+
+func parsePemBoundary(line string, prefix string) (string, bool) {
+	if !strings.HasPrefix(line, prefix) || !strings.HasSuffix(line, PEM_DASHES) {
+		return "", false
+	}
+
+	if len(line) < len(prefix)+len(PEM_DASHES) {
+		return "", false
+	}
+
+	return line[len(prefix) : len(line)-len(PEM_DASHES)], true
+}
+
+// PemDecodeFile finds every PEM block in mixed text and writes the decoded
+// content of blocks labeled label, "*" matches any label. RFC 1421 style
+// headers such as "Proc-Type: 4,ENCRYPTED" are skipped.
+func PemDecodeFile(in io.Reader, out io.Writer, label string) error {
+	reader := bufio.NewReader(in)
+	body := bytes.NewBuffer(nil)
+	found := 0
+	current := ""
+	inBlock := false
+	lineNo := 0
+
+	for {
+		s, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		if len(s) == 0 && err != nil {
+			break
+		}
+
+		lineNo++
+		line := strings.TrimRight(s, " \t\r\n")
+		if !inBlock {
+			if l, ok := parsePemBoundary(line, PEM_BEGIN); ok {
+				if label == PEM_LABEL_MATCH || l == label {
+					current = l
+					inBlock = true
+					body.Reset()
+				}
+			}
+
+		} else if l, ok := parsePemBoundary(line, PEM_END); ok {
+			if l != current {
+				return fmt.Errorf("line %d: PEM block '%s' ended by '%s'", lineNo, current, l)
+			}
+
+			inBlock = false
+			found++
+			if err := Base64DecodeFile(body, out, BASE64_DECODE_MAP, PaddingAuto); err != nil {
+				return fmt.Errorf("PEM block '%s' before line %d: %w", current, lineNo, err)
+			}
+
+		} else if strings.Contains(line, ":") || (line == "" && body.Len() == 0) {
+			// headers and the blank line after them
+
+		} else {
+			body.WriteString(line)
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	if inBlock {
+		return fmt.Errorf("PEM block '%s' is not terminated", current)
+	}
+
+	if found == 0 {
+		if label == PEM_LABEL_MATCH {
+			return errors.New("no PEM block found")
+		}
+		return fmt.Errorf("no PEM block labeled '%s' found", label)
+	}
+
+	return nil
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	UU_LINE_BYTES   = 45
+	UU_DEFAULT_MODE = 0644
+)
+
+var UU_ENCODE_MAP = makeUUEncodeMap()
+
+var XX_ENCODE_MAP = []byte("+-0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz")
+
+var UU_DECODE_MAP = makeUUDecodeMap()
+
+var XX_DECODE_MAP = makeDecodeMap(XX_ENCODE_MAP)
+
+// makeUUEncodeMap returns the 64 characters from ' ' to '_', with 0 mapped
+// to '`' instead of ' ' so lines never end with spaces.
+func makeUUEncodeMap() []byte {
+	m := make([]byte, 64)
+	m[0] = '`'
+	for i := 1; i < 64; i++ {
+		m[i] = byte(' ' + i)
+	}
+
+	return m
+}
+
+// makeUUDecodeMap also accepts ' ' as 0 written by historical encoders.
+func makeUUDecodeMap() []byte {
+	m := makeDecodeMap(UU_ENCODE_MAP)
+	m[' '] = 0
+	return m
+}
+
+type UUHeader struct {
+	Mode os.FileMode
+	Name string
+}
+
+// NewUUHeader makes a header for filename, with the permission bits of the
+// file if it exists.
+func NewUUHeader(filename string) UUHeader {
+	header := UUHeader{
+		Mode: UU_DEFAULT_MODE,
+		Name: filepath.Base(filename),
+	}
+
+	if filename == "-" {
+		return header
+	}
+
+	if info, err := os.Stat(filename); err == nil {
+		header.Mode = info.Mode().Perm()
+	}
+
+	return header
+}
+
+// UUEncodeFile encodes in with uuencode line format, charmap is UU_ENCODE_MAP
+// or XX_ENCODE_MAP.
+func UUEncodeFile(in io.Reader, out io.Writer, charmap []byte, header UUHeader) error {
+	reader := bufio.NewReader(in)
+	buf := make([]byte, UU_LINE_BYTES)
+	line := make([]byte, 1+UU_LINE_BYTES/3*4+1)
+
+	_, err := fmt.Fprintf(out, "begin %03o %s\n", header.Mode.Perm(), header.Name)
+	if err != nil {
+		return err
+	}
+
+	for {
+		n, err := io.ReadFull(reader, buf)
+		if n == 0 {
+			if err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+			break
+		}
+
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+
+		for i := n; i%3 != 0; i++ {
+			buf[i] = 0
+		}
+
+		line[0] = charmap[n]
+		j := 1
+		for i := 0; i < n; i += 3 {
+			line[j+0] = charmap[(buf[i]&0xfc)>>2]
+			line[j+1] = charmap[((buf[i]&0x03)<<4)|((buf[i+1]&0xf0)>>4)]
+			line[j+2] = charmap[((buf[i+1]&0x0f)<<2)|((buf[i+2]&0xc0)>>6)]
+			line[j+3] = charmap[(buf[i+2]&0x3f)>>0]
+			j += 4
+		}
+
+		line[j] = '\n'
+		_, err = out.Write(line[:j+1])
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(out, "%c\nend\n", charmap[0])
+	return err
+}
+
+// parseUUHeader parses a "<keyword> <mode> <name>" line, keyword is "begin"
+// for uuencode and "begin-base64" for uuencode -m.
+func parseUUHeader(line string, keyword string) (*UUHeader, bool) {
+	fields := strings.SplitN(line, " ", 3)
+	if len(fields) != 3 || fields[0] != keyword {
+		return nil, false
+	}
+
+	mode, err := strconv.ParseUint(fields[1], 8, 32)
+	if err != nil {
+		return nil, false
+	}
+
+	header := &UUHeader{
+		Mode: os.FileMode(mode).Perm(),
+		Name: fields[2],
+	}
+
+	return header, true
+}
+
+func readLine(reader *bufio.Reader) (string, error) {
+	s, err := reader.ReadString('\n')
+	if len(s) > 0 && errors.Is(err, io.EOF) {
+		err = nil
+	}
+
+	return strings.TrimRight(s, "\r\n"), err
+}
+
+func uuDecodeBody(reader *bufio.Reader, out io.Writer, decodeMap []byte) error {
+	in := make([]byte, 4)
+	buf := make([]byte, UU_LINE_BYTES+3)
+
+	for lineNo := 1; ; lineNo++ {
+		line, err := readLine(reader)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return errors.New("missing uuencode 'end' line")
+			}
+			return err
+		}
+
+		if line == "end" {
+			return nil
+		}
+
+		if len(line) == 0 {
+			continue
+		}
+
+		n := int(decodeMap[line[0]])
+		if n > UU_LINE_BYTES {
+			return fmt.Errorf("line %d: invalid length character '%c'", lineNo, line[0])
+		}
+
+		j := 0
+		for i := 1; j < n; i += 4 {
+			for k := 0; k < 4; k++ {
+				in[k] = 0
+				if i+k >= len(line) {
+					// trailing spaces may be stripped by mail transports
+					continue
+				}
+
+				v := decodeMap[line[i+k]]
+				if v == 0xff {
+					return fmt.Errorf("line %d: invalid character '%c'", lineNo, line[i+k])
+				}
+				in[k] = v
+			}
+
+			base64DecodeGroup(in, buf[j:j+3], 4)
+			j += 3
+		}
+
+		if _, err := out.Write(buf[:n]); err != nil {
+			return err
+		}
+	}
+}
+
+// safeUUFilename strips directories from the name in a header, so decoding
+// never writes outside of the working directory.
+func safeUUFilename(name string) (string, error) {
+	name = filepath.Base(filepath.Clean(name))
+	if name == "." || name == ".." || name == string(filepath.Separator) || name == "-" {
+		return "", fmt.Errorf("invalid file name '%s' in uuencode header", name)
+	}
+
+	return name, nil
+}
+
+// UUDecodeFile skips text until a "begin" line and decodes the data after it.
+// The output goes to out, or to the file named in the header with its mode
+// if toFile is true.
+func UUDecodeFile(in io.Reader, out io.Writer, decodeMap []byte, toFile bool) error {
+	reader := bufio.NewReader(in)
+
+	var header *UUHeader
+	for header == nil {
+		line, err := readLine(reader)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return errors.New("no uuencode 'begin' line found")
+			}
+			return err
+		}
+
+		header, _ = parseUUHeader(line, "begin")
+	}
+
+	if !toFile {
+		return uuDecodeBody(reader, out, decodeMap)
+	}
+
+	return decodeToHeaderFile(header, func(w io.Writer) error {
+		return uuDecodeBody(reader, w, decodeMap)
+	})
+}
+
+// decodeToHeaderFile creates the file named in header with its mode, and
+// writes the output of decode into it.
+func decodeToHeaderFile(header *UUHeader, decode func(io.Writer) error) error {
+	filename, err := safeUUFilename(header.Name)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, header.Mode)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	err = decode(writer)
+	if err == nil {
+		err = writer.Flush()
+	}
+
+	if errClose := file.Close(); err == nil {
+		err = errClose
+	}
+
+	return err
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUUEncode(t *testing.T) {
+	data := make([]byte, 50)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	cases := []struct {
+		data    []byte
+		charmap []byte
+		header  UUHeader
+		exp     string
+	}{
+		{
+			[]byte("Cat"), UU_ENCODE_MAP, UUHeader{0644, "cat.txt"},
+			"begin 644 cat.txt\n#0V%T\n`\nend\n",
+		},
+		{
+			[]byte("Cat"), XX_ENCODE_MAP, UUHeader{0600, "cat.txt"},
+			"begin 600 cat.txt\n1Eq3o\n+\nend\n",
+		},
+		{
+			data, UU_ENCODE_MAP, UUHeader{0755, "bytes"},
+			"begin 755 bytes\n" +
+				"M``$\"`P0%!@<(\"0H+#`T.#Q`1$A,4%187&!D:&QP='A\\@(2(C)\"4F)R@I*BLL\n" +
+				"%+2XO,#$`\n" +
+				"`\n" +
+				"end\n",
+		},
+	}
+
+	for _, c := range cases {
+		out := bytes.NewBuffer(nil)
+		if err := UUEncodeFile(bytes.NewReader(c.data), out, c.charmap, c.header); err != nil {
+			t.Fatalf("encode failed: %s", err)
+		}
+
+		if out.String() != c.exp {
+			t.Errorf("got:\n%s\nexpected:\n%s", out.String(), c.exp)
+		}
+
+		decodeMap := UU_DECODE_MAP
+		if c.charmap[0] == XX_ENCODE_MAP[0] {
+			decodeMap = XX_DECODE_MAP
+		}
+
+		decoded := bytes.NewBuffer(nil)
+		if err := UUDecodeFile(strings.NewReader("From: someone\n\n"+c.exp), decoded, decodeMap, false); err != nil {
+			t.Fatalf("decode failed: %s", err)
+		}
+
+		if !bytes.Equal(decoded.Bytes(), c.data) {
+			t.Errorf("decode got %x; expected %x", decoded.Bytes(), c.data)
+		}
+	}
+}
+
+func TestUUDecodeStrippedSpaces(t *testing.T) {
+	// historical encoders use ' ' for 0, which may be stripped at line end
+	text := "begin 644 a\n#0V%T\n$0V%T    \n$0V%T\n \nend\n"
+	out := bytes.NewBuffer(nil)
+	if err := UUDecodeFile(strings.NewReader(text), out, UU_DECODE_MAP, false); err != nil {
+		t.Fatalf("decode failed: %s", err)
+	}
+
+	if exp := "CatCat\x00Cat\x00"; out.String() != exp {
+		t.Errorf("got %q; expected %q", out.String(), exp)
+	}
+}
+
+func TestUUDecodeErrors(t *testing.T) {
+	cases := []string{
+		"no header\n",
+		"begin 644 a\n#0V%T\n",
+		"begin 644 a\n#0V~T\n`\nend\n",
+	}
+
+	for _, c := range cases {
+		out := bytes.NewBuffer(nil)
+		if err := UUDecodeFile(strings.NewReader(c), out, UU_DECODE_MAP, false); err == nil {
+			t.Errorf("decode %q expected error", c)
+		}
+	}
+}
+
+func TestUUDecodeToFile(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	data := make([]byte, 100)
+	rand.New(rand.NewSource(30)).Read(data)
+
+	encoded := bytes.NewBuffer(nil)
+	header := UUHeader{0600, "../../escape.bin"}
+	if err := UUEncodeFile(bytes.NewReader(data), encoded, UU_ENCODE_MAP, header); err != nil {
+		t.Fatalf("encode failed: %s", err)
+	}
+
+	if err := UUDecodeFile(encoded, nil, UU_DECODE_MAP, true); err != nil {
+		t.Fatalf("decode failed: %s", err)
+	}
+
+	filename := filepath.Join(dir, "escape.bin")
+	got, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("read decoded file failed: %s", err)
+	}
+
+	if !bytes.Equal(got, data) {
+		t.Errorf("decoded file mismatch")
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("got mode %03o; expected 600", info.Mode().Perm())
+	}
+}
@@ -1,30 +1,57 @@
 package main
 
-import "strings"
+import "hash"
 
+// Checksum is a checksum or digest algorithm, which is also a hash.Hash.
+// Checksum returns the value of checksums up to 8 bytes, and Sum appends the
+// value in big endian to b for any size. The checksums other than digests,
+// and hashes registered from a hash.Hash32 or hash.Hash64, implement
+// hash.Hash64, and those of 4 bytes or less hash.Hash32 too.
 type Checksum interface {
-	Reset()
+	hash.Hash
 	Update([]byte) error
-	Size() int
-	BlockSize() int
 	Checksum() uint64
 }
 
-func NewChecksum(algo string) Checksum {
-	switch strings.ToLower(algo) {
-	case "net":
-		return NewInternetChecksum()
+// checksum64 is a checksum which implements hash.Hash64.
+type checksum64 interface {
+	Checksum
+	Sum64() uint64
+}
+
+// hash32Checksum adds Sum32 to a checksum of 4 bytes or less, whose type
+// may also hold wider checksums, so only narrow ones implement hash.Hash32.
+type hash32Checksum struct {
+	checksum64
+}
+
+func (c hash32Checksum) Sum32() uint32 {
+	return uint32(c.Sum64())
+}
+
+// asHash32 returns c as a hash.Hash32 if it is not longer than 4 bytes.
+func asHash32(c checksum64) Checksum {
+	if c.Size() > 4 {
+		return c
+	}
 
-	case "bsd":
-		return NewBSDChecksum()
+	return hash32Checksum{c}
+}
 
-	default:
-		return nil
+// appendChecksum appends the lowest size bytes of sum to b in big endian.
+func appendChecksum(b []byte, sum uint64, size int) []byte {
+	for i := size - 1; i >= 0; i-- {
+		b = append(b, byte(sum>>(8*i)))
 	}
+
+	return b
 }
 
+// InternetChecksum is the checksum of RFC 1071. The parity of the length of
+// data is kept between updates, so data can be split at any byte.
 type InternetChecksum struct {
 	checksum uint64
+	odd      bool
 }
 
 func NewInternetChecksum() *InternetChecksum {
@@ -36,6 +63,7 @@ func NewInternetChecksum() *InternetChecksum {
 
 func (c *InternetChecksum) Reset() {
 	c.checksum = 0
+	c.odd = false
 }
 
 func (c *InternetChecksum) BlockSize() int {
@@ -47,11 +75,20 @@ func (c *InternetChecksum) Size() int {
 }
 
 func (c *InternetChecksum) Update(data []byte) error {
+	// a byte at an even offset of the whole data is the high byte of a word,
+	// and one's complement addition does not care which word it belongs to
+	offset := 0
+	if c.odd {
+		offset = 1
+	}
+
 	n := [2]uint64{0, 0}
 	for i := 0; i < len(data); i++ {
-		n[i%2] += uint64(data[i])
+		n[(i+offset)%2] += uint64(data[i])
 	}
 
+	c.odd = c.odd != (len(data)%2 == 1)
+
 	s := (n[0] << 8) + n[1]
 	c.checksum += s
 	for c.checksum > 0xffff {
@@ -67,6 +104,49 @@ func (c *InternetChecksum) Checksum() uint64 {
 	return (^c.checksum) & 0x0000ffff
 }
 
+func (c *InternetChecksum) Sum(b []byte) []byte {
+	return appendChecksum(b, c.Checksum(), c.Size())
+}
+
+func (c *InternetChecksum) Write(data []byte) (int, error) {
+	return len(data), c.Update(data)
+}
+
+func (c *InternetChecksum) Sum32() uint32 {
+	return uint32(c.Checksum())
+}
+
+func (c *InternetChecksum) Sum64() uint64 {
+	return c.Checksum()
+}
+
+// onesComplementAdd adds a and b in 16-bit one's complement arithmetic.
+func onesComplementAdd(a uint16, b uint16) uint16 {
+	s := uint32(a) + uint32(b)
+	return uint16(s&0xffff + s>>16)
+}
+
+// UpdateInternetChecksum returns the checksum after a 16-bit field of the
+// data changes from old to new, by equation 3 of RFC 1624,
+// HC' = ~(~HC + ~m + m').
+func UpdateInternetChecksum(checksum uint16, old uint16, new uint16) uint16 {
+	s := onesComplementAdd(^checksum, ^old)
+	return ^onesComplementAdd(s, new)
+}
+
+// UpdateInternetChecksumBytes is UpdateInternetChecksum for a field of
+// several 16-bit words at an even offset of the data, old and new must have
+// the same even length.
+func UpdateInternetChecksumBytes(checksum uint16, old []byte, new []byte) uint16 {
+	s := ^checksum
+	for i := 0; i+1 < len(old) && i+1 < len(new); i += 2 {
+		s = onesComplementAdd(s, ^(uint16(old[i])<<8 | uint16(old[i+1])))
+		s = onesComplementAdd(s, uint16(new[i])<<8|uint16(new[i+1]))
+	}
+
+	return ^s
+}
+
 type BSDChecksum struct {
 	checksum uint64
 }
@@ -106,3 +186,163 @@ func (c *BSDChecksum) Update(data []byte) error {
 func (c *BSDChecksum) Checksum() uint64 {
 	return c.checksum
 }
+
+func (c *BSDChecksum) Sum(b []byte) []byte {
+	return appendChecksum(b, c.Checksum(), c.Size())
+}
+
+func (c *BSDChecksum) Write(data []byte) (int, error) {
+	return len(data), c.Update(data)
+}
+
+func (c *BSDChecksum) Sum32() uint32 {
+	return uint32(c.Checksum())
+}
+
+func (c *BSDChecksum) Sum64() uint64 {
+	return c.Checksum()
+}
+
+// SysVChecksum is the System V sum algorithm, as `sum -s`, counted in
+// 512-byte blocks.
+type SysVChecksum struct {
+	sum uint32
+}
+
+func NewSysVChecksum() *SysVChecksum {
+	c := &SysVChecksum{}
+	c.Reset()
+
+	return c
+}
+
+func (c *SysVChecksum) Reset() {
+	c.sum = 0
+}
+
+func (c *SysVChecksum) BlockSize() int {
+	return 512
+}
+
+func (c *SysVChecksum) Size() int {
+	return 2
+}
+
+func (c *SysVChecksum) Update(data []byte) error {
+	s := c.sum
+	for i := 0; i < len(data); i++ {
+		s += uint32(data[i])
+	}
+
+	c.sum = s
+	return nil
+}
+
+func (c *SysVChecksum) Checksum() uint64 {
+	r := (c.sum & 0xffff) + (c.sum >> 16)
+	return uint64((r & 0xffff) + (r >> 16))
+}
+
+func (c *SysVChecksum) Sum(b []byte) []byte {
+	return appendChecksum(b, c.Checksum(), c.Size())
+}
+
+func (c *SysVChecksum) Write(data []byte) (int, error) {
+	return len(data), c.Update(data)
+}
+
+func (c *SysVChecksum) Sum32() uint32 {
+	return uint32(c.Checksum())
+}
+
+func (c *SysVChecksum) Sum64() uint64 {
+	return c.Checksum()
+}
+
+// POSIX_CRC32_TABLE is the MSB-first lookup table of the CRC-32 polynomial
+// 0x04c11db7 used by POSIX cksum.
+var POSIX_CRC32_TABLE = makePosixCRC32Table(0x04c11db7)
+
+func makePosixCRC32Table(poly uint32) []uint32 {
+	table := make([]uint32, 256)
+	for i := range table {
+		crc := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if crc&0x80000000 != 0 {
+				crc = (crc << 1) ^ poly
+			} else {
+				crc <<= 1
+			}
+		}
+
+		table[i] = crc
+	}
+
+	return table
+}
+
+// PosixChecksum is the CRC-32 of POSIX cksum, the length of data is folded
+// into the CRC after data, least significant byte first.
+type PosixChecksum struct {
+	crc    uint32
+	length uint64
+}
+
+func NewPosixChecksum() *PosixChecksum {
+	c := &PosixChecksum{}
+	c.Reset()
+
+	return c
+}
+
+func (c *PosixChecksum) Reset() {
+	c.crc = 0
+	c.length = 0
+}
+
+func (c *PosixChecksum) BlockSize() int {
+	return 1024
+}
+
+func (c *PosixChecksum) Size() int {
+	return 4
+}
+
+func posixCRC32Update(crc uint32, data []byte) uint32 {
+	for _, b := range data {
+		crc = (crc << 8) ^ POSIX_CRC32_TABLE[byte(crc>>24)^b]
+	}
+
+	return crc
+}
+
+func (c *PosixChecksum) Update(data []byte) error {
+	c.crc = posixCRC32Update(c.crc, data)
+	c.length += uint64(len(data))
+	return nil
+}
+
+func (c *PosixChecksum) Checksum() uint64 {
+	crc := c.crc
+	for n := c.length; n > 0; n >>= 8 {
+		crc = posixCRC32Update(crc, []byte{byte(n)})
+	}
+
+	return uint64(^crc)
+}
+
+func (c *PosixChecksum) Sum(b []byte) []byte {
+	return appendChecksum(b, c.Checksum(), c.Size())
+}
+
+func (c *PosixChecksum) Write(data []byte) (int, error) {
+	return len(data), c.Update(data)
+}
+
+func (c *PosixChecksum) Sum32() uint32 {
+	return uint32(c.Checksum())
+}
+
+func (c *PosixChecksum) Sum64() uint64 {
+	return c.Checksum()
+}
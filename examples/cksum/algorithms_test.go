@@ -1,6 +1,8 @@
 package main
 
 import (
+	"math/rand"
+	"strings"
 	"testing"
 )
 
@@ -37,3 +39,133 @@ func TestBSDSum(t *testing.T) {
 		t.Errorf("got %016x; expected %016x", got, exp)
 	}
 }
+
+func TestPosixChecksum(t *testing.T) {
+	// reference outputs of GNU coreutils cksum
+	cases := []struct {
+		data string
+		exp  uint64
+	}{
+		{"", 4294967295},
+		{"a", 1220704766},
+		{"123456789", 930766865},
+		{"The quick brown fox jumps over the lazy dog", 2074844392},
+		{string(make([]byte, 100000)), 1260869142},
+	}
+
+	c := NewPosixChecksum()
+	for _, cc := range cases {
+		c.Reset()
+		data := []byte(cc.data)
+		for len(data) > 0 {
+			n := len(data)
+			if n > 1000 {
+				n = 1000
+			}
+
+			_ = c.Update(data[:n])
+			data = data[n:]
+		}
+
+		if got := c.Checksum(); got != cc.exp {
+			t.Errorf("cksum of %d bytes: got %d; expected %d", len(cc.data), got, cc.exp)
+		}
+	}
+}
+
+func TestSysVChecksum(t *testing.T) {
+	// reference outputs of GNU coreutils sum -s
+	cases := []struct {
+		data string
+		exp  uint64
+	}{
+		{"", 0},
+		{"abc", 294},
+		{"123456789", 477},
+		{strings.Repeat("a", 100000), 820},
+	}
+
+	c := NewSysVChecksum()
+	for _, cc := range cases {
+		c.Reset()
+		_ = c.Update([]byte(cc.data))
+		if got := c.Checksum(); got != cc.exp {
+			t.Errorf("sum -s of %d bytes: got %d; expected %d", len(cc.data), got, cc.exp)
+		}
+	}
+}
+
+// referenceInternetChecksum computes RFC 1071 checksum of data in one pass.
+func referenceInternetChecksum(data []byte) uint16 {
+	sum := uint32(0)
+	for i := 0; i < len(data); i += 2 {
+		w := uint32(data[i]) << 8
+		if i+1 < len(data) {
+			w |= uint32(data[i+1])
+		}
+
+		sum += w
+		sum = (sum & 0xffff) + (sum >> 16)
+	}
+
+	return ^uint16(sum)
+}
+
+func TestInternetChecksumChunks(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	c := NewInternetChecksum()
+	for round := 0; round < 200; round++ {
+		data := make([]byte, r.Intn(3000))
+		r.Read(data)
+		exp := uint64(referenceInternetChecksum(data))
+
+		c.Reset()
+		updateInChunks(c, data, r)
+		if got := c.Checksum(); got != exp {
+			t.Fatalf("round %d, %d bytes: got %04x; expected %04x", round, len(data), got, exp)
+		}
+	}
+}
+
+func TestUpdateInternetChecksum(t *testing.T) {
+	// the example in section 4 of RFC 1624
+	if got := UpdateInternetChecksum(0xdd2f, 0x5555, 0x3285); got != 0x0000 {
+		t.Errorf("got %04x; expected 0000", got)
+	}
+
+	header := []byte{
+		0x45, 0x00, 0x00, 0x73,
+		0x00, 0x00, 0x40, 0x00,
+		0x40, 0x11, 0x00, 0x00,
+		0xc0, 0xa8, 0x00, 0x01,
+		0xc0, 0xa8, 0x00, 0xc7,
+	}
+
+	r := rand.New(rand.NewSource(5))
+	for i := 0; i < 100; i++ {
+		checksum := referenceInternetChecksum(header)
+
+		// change a word other than the checksum field at offset 10
+		offset := 2 * r.Intn(10)
+		if offset == 10 {
+			offset = 8
+		}
+
+		old := []byte{header[offset], header[offset+1]}
+		header[offset], header[offset+1] = byte(r.Intn(256)), byte(r.Intn(256))
+		exp := referenceInternetChecksum(header)
+
+		got := UpdateInternetChecksumBytes(checksum, old, header[offset:offset+2])
+		w := func(b []byte) uint16 { return uint16(b[0])<<8 | uint16(b[1]) }
+		got16 := UpdateInternetChecksum(checksum, w(old), w(header[offset:]))
+
+		// 0x0000 and 0xffff are both zero in one's complement
+		if got != exp && got^exp != 0xffff {
+			t.Fatalf("case %d: got %04x; expected %04x", i, got, exp)
+		}
+
+		if got16 != got {
+			t.Fatalf("case %d: 16-bit update got %04x; expected %04x", i, got16, got)
+		}
+	}
+}
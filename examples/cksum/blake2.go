@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+const (
+	BLAKE2B_BLOCK_SIZE = 128
+	BLAKE2S_BLOCK_SIZE = 64
+)
+
+var BLAKE2B_IV = [8]uint64{
+	0x6a09e667f3bcc908, 0xbb67ae8584caa73b, 0x3c6ef372fe94f82b, 0xa54ff53a5f1d36f1,
+	0x510e527fade682d1, 0x9b05688c2b3e6c1f, 0x1f83d9abfb41bd6b, 0x5be0cd19137e2179,
+}
+
+var BLAKE2S_IV = [8]uint32{
+	0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a,
+	0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19,
+}
+
+var BLAKE2_SIGMA = [10][16]int{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+	{14, 10, 4, 8, 9, 15, 13, 6, 1, 12, 0, 2, 11, 7, 5, 3},
+	{11, 8, 12, 0, 5, 2, 15, 13, 10, 14, 3, 6, 7, 1, 9, 4},
+	{7, 9, 3, 1, 13, 12, 11, 14, 2, 6, 5, 10, 4, 0, 15, 8},
+	{9, 0, 5, 7, 2, 4, 10, 15, 14, 1, 11, 12, 6, 8, 3, 13},
+	{2, 12, 6, 10, 0, 11, 8, 3, 4, 13, 7, 5, 15, 14, 1, 9},
+	{12, 5, 1, 15, 14, 13, 4, 10, 0, 7, 6, 3, 9, 2, 8, 11},
+	{13, 11, 7, 14, 12, 1, 3, 9, 5, 0, 15, 4, 8, 6, 2, 10},
+	{6, 15, 14, 9, 11, 3, 0, 8, 12, 2, 13, 7, 1, 4, 10, 5},
+	{10, 2, 8, 4, 7, 6, 1, 5, 15, 11, 9, 14, 3, 12, 13, 0},
+}
+
+// BLAKE2_G_LANES are the lanes of the column and diagonal steps of a round.
+var BLAKE2_G_LANES = [8][4]int{
+	{0, 4, 8, 12}, {1, 5, 9, 13}, {2, 6, 10, 14}, {3, 7, 11, 15},
+	{0, 5, 10, 15}, {1, 6, 11, 12}, {2, 7, 8, 13}, {3, 4, 9, 14},
+}
+
+// blake2Buffer keeps the last block of input until more data arrives, since
+// the final block must be compressed with the final flag.
+type blake2Buffer struct {
+	buf       []byte
+	blockSize int
+}
+
+func (b *blake2Buffer) write(data []byte, compress func([]byte)) {
+	if len(b.buf) > 0 && len(data) > 0 {
+		k := copy(b.buf[len(b.buf):b.blockSize], data)
+		b.buf = b.buf[:len(b.buf)+k]
+		data = data[k:]
+		if len(data) == 0 {
+			return
+		}
+
+		compress(b.buf)
+		b.buf = b.buf[:0]
+	}
+
+	for len(data) > b.blockSize {
+		compress(data[:b.blockSize])
+		data = data[b.blockSize:]
+	}
+
+	b.buf = append(b.buf, data...)
+}
+
+// BLAKE2b is the unkeyed BLAKE2b hash of RFC 7693 with a digest of size bytes.
+type BLAKE2b struct {
+	h      [8]uint64
+	t      uint64
+	size   int
+	buffer blake2Buffer
+}
+
+// NewBLAKE2b returns a BLAKE2b hash with a digest of 1 to 64 bytes.
+func NewBLAKE2b(size int) hash.Hash {
+	d := &BLAKE2b{
+		size:   size,
+		buffer: blake2Buffer{make([]byte, 0, BLAKE2B_BLOCK_SIZE), BLAKE2B_BLOCK_SIZE},
+	}
+	d.Reset()
+
+	return d
+}
+
+func (d *BLAKE2b) Reset() {
+	d.h = BLAKE2B_IV
+	d.h[0] ^= 0x01010000 ^ uint64(d.size)
+	d.t = 0
+	d.buffer.buf = d.buffer.buf[:0]
+}
+
+func (d *BLAKE2b) Size() int {
+	return d.size
+}
+
+func (d *BLAKE2b) BlockSize() int {
+	return BLAKE2B_BLOCK_SIZE
+}
+
+func (d *BLAKE2b) compress(block []byte, length int, last bool) {
+	var m [16]uint64
+	for i := range m {
+		m[i] = binary.LittleEndian.Uint64(block[8*i:])
+	}
+
+	d.t += uint64(length)
+	var v [16]uint64
+	copy(v[:8], d.h[:])
+	copy(v[8:], BLAKE2B_IV[:])
+	v[12] ^= d.t
+	if last {
+		v[14] = ^v[14]
+	}
+
+	for round := 0; round < 12; round++ {
+		s := &BLAKE2_SIGMA[round%10]
+		for i, l := range BLAKE2_G_LANES {
+			a, b, c, e := l[0], l[1], l[2], l[3]
+			v[a] += v[b] + m[s[2*i]]
+			v[e] = bits.RotateLeft64(v[e]^v[a], -32)
+			v[c] += v[e]
+			v[b] = bits.RotateLeft64(v[b]^v[c], -24)
+			v[a] += v[b] + m[s[2*i+1]]
+			v[e] = bits.RotateLeft64(v[e]^v[a], -16)
+			v[c] += v[e]
+			v[b] = bits.RotateLeft64(v[b]^v[c], -63)
+		}
+	}
+
+	for i := 0; i < 8; i++ {
+		d.h[i] ^= v[i] ^ v[i+8]
+	}
+}
+
+func (d *BLAKE2b) Write(data []byte) (int, error) {
+	d.buffer.write(data, func(block []byte) {
+		d.compress(block, BLAKE2B_BLOCK_SIZE, false)
+	})
+
+	return len(data), nil
+}
+
+func (d *BLAKE2b) Sum(b []byte) []byte {
+	c := *d
+	block := make([]byte, BLAKE2B_BLOCK_SIZE)
+	copy(block, d.buffer.buf)
+	c.compress(block, len(d.buffer.buf), true)
+
+	out := make([]byte, 64)
+	for i, x := range c.h {
+		binary.LittleEndian.PutUint64(out[8*i:], x)
+	}
+
+	return append(b, out[:d.size]...)
+}
+
+// BLAKE2s is the unkeyed BLAKE2s hash of RFC 7693 with a digest of size bytes.
+type BLAKE2s struct {
+	h      [8]uint32
+	t      uint64
+	size   int
+	buffer blake2Buffer
+}
+
+// NewBLAKE2s returns a BLAKE2s hash with a digest of 1 to 32 bytes.
+func NewBLAKE2s(size int) hash.Hash {
+	d := &BLAKE2s{
+		size:   size,
+		buffer: blake2Buffer{make([]byte, 0, BLAKE2S_BLOCK_SIZE), BLAKE2S_BLOCK_SIZE},
+	}
+	d.Reset()
+
+	return d
+}
+
+func (d *BLAKE2s) Reset() {
+	d.h = BLAKE2S_IV
+	d.h[0] ^= 0x01010000 ^ uint32(d.size)
+	d.t = 0
+	d.buffer.buf = d.buffer.buf[:0]
+}
+
+func (d *BLAKE2s) Size() int {
+	return d.size
+}
+
+func (d *BLAKE2s) BlockSize() int {
+	return BLAKE2S_BLOCK_SIZE
+}
+
+func (d *BLAKE2s) compress(block []byte, length int, last bool) {
+	var m [16]uint32
+	for i := range m {
+		m[i] = binary.LittleEndian.Uint32(block[4*i:])
+	}
+
+	d.t += uint64(length)
+	var v [16]uint32
+	copy(v[:8], d.h[:])
+	copy(v[8:], BLAKE2S_IV[:])
+	v[12] ^= uint32(d.t)
+	v[13] ^= uint32(d.t >> 32)
+	if last {
+		v[14] = ^v[14]
+	}
+
+	for round := 0; round < 10; round++ {
+		s := &BLAKE2_SIGMA[round]
+		for i, l := range BLAKE2_G_LANES {
+			a, b, c, e := l[0], l[1], l[2], l[3]
+			v[a] += v[b] + m[s[2*i]]
+			v[e] = bits.RotateLeft32(v[e]^v[a], -16)
+			v[c] += v[e]
+			v[b] = bits.RotateLeft32(v[b]^v[c], -12)
+			v[a] += v[b] + m[s[2*i+1]]
+			v[e] = bits.RotateLeft32(v[e]^v[a], -8)
+			v[c] += v[e]
+			v[b] = bits.RotateLeft32(v[b]^v[c], -7)
+		}
+	}
+
+	for i := 0; i < 8; i++ {
+		d.h[i] ^= v[i] ^ v[i+8]
+	}
+}
+
+func (d *BLAKE2s) Write(data []byte) (int, error) {
+	d.buffer.write(data, func(block []byte) {
+		d.compress(block, BLAKE2S_BLOCK_SIZE, false)
+	})
+
+	return len(data), nil
+}
+
+func (d *BLAKE2s) Sum(b []byte) []byte {
+	c := *d
+	block := make([]byte, BLAKE2S_BLOCK_SIZE)
+	copy(block, d.buffer.buf)
+	c.compress(block, len(d.buffer.buf), true)
+
+	out := make([]byte, 32)
+	for i, x := range c.h {
+		binary.LittleEndian.PutUint32(out[4*i:], x)
+	}
+
+	return append(b, out[:d.size]...)
+}
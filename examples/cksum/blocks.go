@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bufio"
+	"crypto/md5"
+	"errors"
+	"io"
+)
+
+// ReadBlocks calls fn with every block of size bytes of in, and the offset
+// of the block. The last block may be shorter.
+func ReadBlocks(in io.Reader, size int, fn func(offset int, block []byte) error) error {
+	if size <= 0 {
+		return errors.New("block size must be positive")
+	}
+
+	buf := make([]byte, size)
+	for offset := 0; ; {
+		n, err := io.ReadFull(in, buf)
+		if n > 0 {
+			if errFn := fn(offset, buf[:n]); errFn != nil {
+				return errFn
+			}
+			offset += n
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// BlockSignature is the weak rolling checksum and strong MD5 digest of a
+// block, as rsync sends for each block of the old file.
+type BlockSignature struct {
+	Offset int
+	Length int
+	Weak   uint64
+	Strong [md5.Size]byte
+}
+
+// BlockSignatures returns the signatures of every block of size bytes of in.
+func BlockSignatures(in io.Reader, size int, checksum RollingChecksum) ([]BlockSignature, error) {
+	signatures := make([]BlockSignature, 0)
+	err := ReadBlocks(in, size, func(offset int, block []byte) error {
+		checksum.Reset()
+		_ = checksum.Update(block)
+		signatures = append(signatures, BlockSignature{offset, len(block), checksum.Checksum(), md5.Sum(block)})
+		return nil
+	})
+
+	return signatures, err
+}
+
+// BlockMatch is a range of the new file. Source is the offset of the block
+// of the old file with the same content, or -1 if no block matches.
+type BlockMatch struct {
+	Offset int
+	Length int
+	Source int
+}
+
+func (m BlockMatch) Matched() bool {
+	return m.Source >= 0
+}
+
+// blockWindow is a window sliding over a reader, kept in a buffer larger
+// than the window so bytes are moved only when the buffer is used up.
+type blockWindow struct {
+	reader *bufio.Reader
+	buf    []byte
+	start  int
+	end    int
+}
+
+func newBlockWindow(in io.Reader, size int) *blockWindow {
+	return &blockWindow{reader: bufio.NewReader(in), buf: make([]byte, 4*size)}
+}
+
+func (w *blockWindow) bytes() []byte {
+	return w.buf[w.start:w.end]
+}
+
+// fill starts a new window of up to size bytes after the current one.
+func (w *blockWindow) fill(size int) error {
+	w.start, w.end = 0, 0
+	n, err := io.ReadFull(w.reader, w.buf[:size])
+	w.end = n
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil
+	}
+
+	return err
+}
+
+// slide moves the window by one byte, and returns false at the end of
+// input.
+func (w *blockWindow) slide() (byte, byte, bool, error) {
+	in, err := w.reader.ReadByte()
+	if errors.Is(err, io.EOF) {
+		return 0, 0, false, nil
+	}
+
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	if w.end == len(w.buf) {
+		w.end = copy(w.buf, w.buf[w.start:w.end])
+		w.start = 0
+	}
+
+	out := w.buf[w.start]
+	w.buf[w.end] = in
+	w.start++
+	w.end++
+	return out, in, true, nil
+}
+
+// DiffBlocks finds the blocks of signatures in the new file in, at any
+// offset, as rsync does. It returns the matched blocks and the ranges
+// between them in order. The window slides by one byte with checksum, and
+// the strong digest is only computed when the weak checksum matches.
+func DiffBlocks(signatures []BlockSignature, in io.Reader, size int, checksum RollingChecksum) ([]BlockMatch, error) {
+	if size <= 0 {
+		return nil, errors.New("block size must be positive")
+	}
+
+	index := make(map[uint64][]int)
+	for i, s := range signatures {
+		index[s.Weak] = append(index[s.Weak], i)
+	}
+
+	// a window of repeated content prefers the old block at the same offset,
+	// then one not matched yet, so each old block is used once if possible
+	used := make([]bool, len(signatures))
+	find := func(window []byte, weak uint64, offset int) int {
+		best := -1
+		strong := md5.Sum(window)
+		for _, i := range index[weak] {
+			s := signatures[i]
+			if s.Length != len(window) || s.Strong != strong {
+				continue
+			}
+
+			if s.Offset == offset {
+				best = i
+				break
+			}
+
+			if best < 0 || (used[best] && !used[i]) {
+				best = i
+			}
+		}
+
+		if best < 0 {
+			return -1
+		}
+
+		used[best] = true
+		return signatures[best].Offset
+	}
+
+	matches := make([]BlockMatch, 0)
+	differ := func(from int, to int) {
+		if to > from {
+			matches = append(matches, BlockMatch{from, to - from, -1})
+		}
+	}
+
+	window := newBlockWindow(in, size)
+	if err := window.fill(size); err != nil {
+		return nil, err
+	}
+
+	checksum.Reset()
+	_ = checksum.Update(window.bytes())
+	literal, offset := 0, 0
+	for len(window.bytes()) == size {
+		if source := find(window.bytes(), checksum.Checksum(), offset); source >= 0 {
+			differ(literal, offset)
+			matches = append(matches, BlockMatch{offset, size, source})
+			offset += size
+			literal = offset
+
+			if err := window.fill(size); err != nil {
+				return nil, err
+			}
+
+			checksum.Reset()
+			_ = checksum.Update(window.bytes())
+			continue
+		}
+
+		out, next, ok, err := window.slide()
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			break
+		}
+
+		checksum.Roll(out, next)
+		offset++
+	}
+
+	// only the last block of the old file can be shorter, so the tail is
+	// checked once for it instead of shrinking the window
+	tail := window.bytes()
+	if len(signatures) > 0 {
+		last := signatures[len(signatures)-1]
+		if last.Length < size && last.Length <= len(tail) {
+			block := tail[len(tail)-last.Length:]
+			end := offset + len(tail) - last.Length
+			checksum.Reset()
+			_ = checksum.Update(block)
+			if source := find(block, checksum.Checksum(), end); source >= 0 {
+				differ(literal, end)
+				matches = append(matches, BlockMatch{end, last.Length, source})
+				return matches, nil
+			}
+		}
+	}
+
+	differ(literal, offset+len(tail))
+	return matches, nil
+}
+
+// UnmatchedBlocks returns the blocks of signatures which no match uses, the
+// blocks removed from the old file.
+func UnmatchedBlocks(signatures []BlockSignature, matches []BlockMatch) []BlockSignature {
+	used := make(map[int]bool)
+	for _, m := range matches {
+		if m.Matched() {
+			used[m.Source] = true
+		}
+	}
+
+	unmatched := make([]BlockSignature, 0)
+	for _, s := range signatures {
+		if !used[s.Offset] {
+			unmatched = append(unmatched, s)
+		}
+	}
+
+	return unmatched
+}
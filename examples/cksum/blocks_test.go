@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadBlocks(t *testing.T) {
+	got := make([]int, 0)
+	err := ReadBlocks(bytes.NewReader(make([]byte, 10)), 4, func(offset int, block []byte) error {
+		got = append(got, offset, len(block))
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("read failed: %s", err)
+	}
+
+	if exp := []int{0, 4, 4, 4, 8, 2}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("got %v; expected %v", got, exp)
+	}
+}
+
+func TestDiffBlocks(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	old := make([]byte, 70)
+	r.Read(old)
+
+	// 3 bytes inserted in the second block, and the first block changed
+	changed := append([]byte{}, old[:16]...)
+	changed[5] ^= 0xff
+	changed = append(changed, old[16:20]...)
+	changed = append(changed, 'x', 'y', 'z')
+	changed = append(changed, old[20:]...)
+
+	cases := []struct {
+		name string
+		data []byte
+		exp  []BlockMatch
+	}{
+		{"same", old, []BlockMatch{{0, 16, 0}, {16, 16, 16}, {32, 16, 32}, {48, 16, 48}, {64, 6, 64}}},
+		{"changed", changed, []BlockMatch{{0, 35, -1}, {35, 16, 32}, {51, 16, 48}, {67, 6, 64}}},
+		{"moved", append(append([]byte{}, old[48:]...), old[:48]...),
+			[]BlockMatch{{0, 16, 48}, {16, 6, -1}, {22, 16, 0}, {38, 16, 16}, {54, 16, 32}}},
+		{"empty", nil, []BlockMatch{}},
+	}
+
+	for _, algo := range []string{"rsync", "buzhash", "adler32"} {
+		checksum := NewChecksum(algo).(RollingChecksum)
+		signatures, err := BlockSignatures(bytes.NewReader(old), 16, checksum)
+		if err != nil {
+			t.Fatalf("signatures failed: %s", err)
+		}
+
+		for _, c := range cases {
+			got, err := DiffBlocks(signatures, bytes.NewReader(c.data), 16, checksum)
+			if err != nil {
+				t.Fatalf("%s %s: diff failed: %s", algo, c.name, err)
+			}
+
+			if !reflect.DeepEqual(got, c.exp) {
+				t.Errorf("%s %s: got %v; expected %v", algo, c.name, got, c.exp)
+			}
+		}
+	}
+}
+
+func TestDiffFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("write failed: %s", err)
+		}
+		return path
+	}
+
+	r := rand.New(rand.NewSource(5))
+	data := make([]byte, 64)
+	r.Read(data)
+
+	zeros := write("zeros", make([]byte, 4096))
+	one := write("one", []byte("A"))
+	four := write("four", []byte("AAAA"))
+	ab := write("ab", []byte("AB"))
+	ba := write("ba", []byte("BA"))
+	o := write("o", data)
+	oo := write("oo", append(append([]byte{}, data...), data...))
+	truncated := write("truncated", data[:40])
+	empty := write("empty", nil)
+
+	// each old block is used once if possible, repeated ones are moved, and
+	// blocks no match uses are removed
+	cases := []struct {
+		old    string
+		new    string
+		size   int
+		same   bool
+		found  string
+		counts string
+	}{
+		{zeros, zeros, 1024, true, "4 of 4 blocks", "0 bytes differ, 0 bytes moved"},
+		{one, four, 1, false, "1 of 1 blocks", "0 bytes differ, 3 bytes moved"},
+		{four, one, 1, false, "1 of 4 blocks", "3 bytes differ, 0 bytes moved"},
+		{ab, ba, 1, false, "2 of 2 blocks", "0 bytes differ, 2 bytes moved"},
+		{ab, ab, 1, true, "2 of 2 blocks", "0 bytes differ, 0 bytes moved"},
+		{oo, o, 16, false, "4 of 8 blocks", "64 bytes differ, 0 bytes moved"},
+		{o, oo, 16, false, "4 of 4 blocks", "0 bytes differ, 64 bytes moved"},
+		{o, truncated, 16, false, "2 of 4 blocks", "40 bytes differ, 0 bytes moved"},
+		{o, empty, 16, false, "0 of 4 blocks", "64 bytes differ, 0 bytes moved"},
+	}
+
+	for _, c := range cases {
+		out := bytes.NewBuffer(nil)
+		same, err := diffFiles(out, c.old, c.new, c.size, NewRsyncChecksum())
+		if err != nil {
+			t.Fatalf("diff failed: %s", err)
+		}
+
+		if same != c.same {
+			t.Errorf("%s %s: got same %v; expected %v", c.old, c.new, same, c.same)
+		}
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		summary := lines[len(lines)-1]
+		if !strings.HasPrefix(summary, c.found) || !strings.HasSuffix(summary, c.counts) {
+			t.Errorf("%s %s: got summary %q; expected %q and %q", c.old, c.new, summary, c.found, c.counts)
+		}
+	}
+
+	out := bytes.NewBuffer(nil)
+	_, _ = diffFiles(out, o, truncated, 16, NewRsyncChecksum())
+	exp := "0 16 match 0\n16 16 match 16\n32 8 differ\n32 16 removed\n48 16 removed\n"
+	if !strings.HasPrefix(out.String(), exp) {
+		t.Errorf("truncated: got\n%s\nexpected\n%s", out.String(), exp)
+	}
+}
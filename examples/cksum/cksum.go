@@ -1,16 +1,30 @@
 package main
 
 import (
+	"encoding/hex"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	READ_BUFFER_SIZE        = 32 * 1024
+	DEFAULT_DIFF_BLOCK_SIZE = 1024
+	DEFAULT_DIFF_ALGORITHM  = "rsync"
 )
 
 func usage() {
-	fmt.Printf("Usage: %s [file...]\n", os.Args[0])
+	fmt.Printf("Usage: %s [-a algorithm] [-manifest | -tag] [-r] [file...]\n", os.Args[0])
+	fmt.Printf("       %s -c [-quiet] [-status] [-strict] [manifest...]\n", os.Args[0])
+	fmt.Printf("       %s -packet raw|pcap [file...]\n", os.Args[0])
+	fmt.Printf("       %s -blocks N [-a algorithm] [file...]\n", os.Args[0])
+	fmt.Printf("       %s -diff [-blocks N] [-a algorithm] old new\n", os.Args[0])
 	fmt.Printf("Display file checksum and block count, like `sum` in Linux and `cksum` in macOS\n")
+	flag.PrintDefaults()
 }
 
 func openFile(name string) (io.ReadCloser, error) {
@@ -21,69 +35,451 @@ func openFile(name string) (io.ReadCloser, error) {
 	return os.Open(name)
 }
 
-func main() {
-	algo := flag.String("a", "net", "algorithm to use")
-	expect := flag.Uint64("check", 0, "checksum to check")
-	flag.Usage = usage
-	flag.Parse()
+// matchChecksum compares the checksum with expect, which is a number for
+// checksums up to 8 bytes, or a hex digest of any size.
+func matchChecksum(checksum Checksum, expect string) bool {
+	if checksum.Size() <= 8 {
+		if n, err := strconv.ParseUint(expect, 0, 64); err == nil {
+			return n == checksum.Checksum()
+		}
+	}
 
-	toCheck := false
-	flag.Visit(func(f *flag.Flag) {
-		if f.Name == "check" {
-			toCheck = true
+	return strings.EqualFold(expect, hex.EncodeToString(checksum.Sum(nil)))
+}
+
+// checksumFile updates checksums with the content of file name after reset,
+// reading the file once, and returns the length of file.
+func checksumFile(name string, checksums ...Checksum) (int, error) {
+	file, err := openFile(name)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	writers := make([]io.Writer, len(checksums))
+	for i, c := range checksums {
+		c.Reset()
+		writers[i] = c
+	}
+
+	length, err := io.CopyBuffer(io.MultiWriter(writers...), file, make([]byte, READ_BUFFER_SIZE))
+	return int(length), err
+}
+
+// countBlocks counts length bytes in the block unit of the checksum, and a
+// partial block counts as a whole one.
+func countBlocks(checksum Checksum, length int) int {
+	blockSize := checksum.BlockSize()
+	return (length + blockSize - 1) / blockSize
+}
+
+// formatResult formats the checksum of file name in the output format of
+// algo. Tagged lines name algo by its registered name, whatever alias is
+// given.
+func formatResult(conf *CksumConfigure, algo *Algorithm, checksum Checksum, name string, length int) string {
+	checkResult := ""
+	if conf.ToCheck {
+		if matchChecksum(checksum, conf.Expect) {
+			checkResult = " [correct]"
+		} else {
+			checkResult = " [wrong]"
 		}
-	})
+	}
 
-	checksum := NewChecksum(*algo)
+	if conf.Manifest || conf.Tag {
+		return FormatManifestLine(algo.Name, name, checksum.Sum(nil), conf.Tag) + checkResult
+	}
 
-	fileList := []string{"-"}
-	if flag.NArg() > 0 {
-		fileList = flag.Args()
+	// the output of POSIX cksum is the checksum, byte count and name,
+	// without name for standard input
+	sum := checksum.Checksum()
+	if _, ok := checksum.(*PosixChecksum); ok {
+		if name == "-" {
+			return fmt.Sprintf("%d %d%s", sum, length, checkResult)
+		}
+
+		return fmt.Sprintf("%d %d %s%s", sum, length, name, checkResult)
+	}
+
+	// digests longer than 8 bytes are printed in hex as sha256sum does
+	if checksum.Size() > 8 {
+		return fmt.Sprintf("%x  %s%s", checksum.Sum(nil), name, checkResult)
+	}
+
+	digits := 2 * checksum.Size()
+	return fmt.Sprintf("%d 0x%0*x %d %s%s", sum, digits, sum, countBlocks(checksum, length), name, checkResult)
+}
+
+type CksumConfigure struct {
+	Algorithm string
+	Expect    string
+	ToCheck   bool
+	Manifest  bool
+	Tag       bool
+	Verify    bool
+	Quiet     bool
+	Status    bool
+	Strict    bool
+	Recursive bool
+	Include   GlobList
+	Exclude   GlobList
+	Jobs      int
+	Packet    string
+	List      bool
+	Format    string
+	Blocks    int
+	Diff      bool
+	Files     []string
+}
+
+func initFlags(conf *CksumConfigure) {
+	flag.StringVar(&conf.Algorithm, "a", "net", "algorithms to use separated by commas, see -list for all algorithms")
+	flag.StringVar(&conf.Format, "format", "plain", "output format, plain, csv, tsv or json, or csv-wide, tsv-wide or jsonl for one row for each file")
+	flag.BoolVar(&conf.List, "list", false, "list all algorithms with their output size in bytes")
+	flag.StringVar(&conf.Expect, "check", "", "checksum to check, a number or a hex digest")
+	flag.BoolVar(&conf.Manifest, "manifest", false, "output in GNU *sum manifest format, \"hex  file\"")
+	flag.BoolVar(&conf.Tag, "tag", false, "output in BSD tagged manifest format, \"ALGO (file) = hex\"")
+	flag.BoolVar(&conf.Verify, "c", false, "read checksums from manifest files and verify them, untagged lines use -a or the algorithm of their digest length")
+	flag.BoolVar(&conf.Quiet, "quiet", false, "do not print OK for each verified file")
+	flag.BoolVar(&conf.Status, "status", false, "print nothing when verifying, exit status shows the result")
+	flag.BoolVar(&conf.Strict, "strict", false, "fail on improperly formatted manifest lines")
+	flag.BoolVar(&conf.Recursive, "r", false, "checksum files in directories recursively")
+	flag.Var(&conf.Include, "include", "glob of file names or relative paths to include in -r mode, can be repeated")
+	flag.Var(&conf.Exclude, "exclude", "glob of file names or relative paths to exclude in -r mode, can be repeated")
+	flag.StringVar(&conf.Packet, "packet", "", "check IP, TCP, UDP and ICMP checksums of files of raw packets or pcap captures, raw or pcap")
+	flag.IntVar(&conf.Jobs, "j", 0, "number of files to checksum concurrently, 0 means the number of CPUs")
+	flag.IntVar(&conf.Blocks, "blocks", 0, "print the checksum of every block of N bytes, or the block size of -diff")
+	flag.BoolVar(&conf.Diff, "diff", false, "find blocks of the old file in the new file with a rolling checksum, exit status is 1 if they differ")
+}
+
+// runVerify verifies every manifest in conf, and returns the exit status.
+func runVerify(conf *CksumConfigure) int {
+	status := 0
+	opts := VerifyOptions{
+		Quiet:  conf.Quiet,
+		Status: conf.Status,
+		Strict: conf.Strict,
 	}
 
-	for _, arg := range fileList {
-		file, err := openFile(arg)
+	for _, name := range conf.Files {
+		file, err := openFile(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			status = 1
+			continue
+		}
+
+		result, err := VerifyManifest(file, os.Stdout, conf.Algorithm, opts)
+		file.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			status = 1
+			continue
+		}
+
+		if !result.OK(conf.Strict) {
+			status = 1
+		}
+
+		if conf.Status {
+			continue
+		}
+
+		if result.Matched+result.Failed+result.Unreadable == 0 {
+			fmt.Fprintf(os.Stderr, "%s: no properly formatted checksum lines found\n", name)
+			continue
+		}
+
+		for _, warning := range result.Warnings() {
+			fmt.Fprintln(os.Stderr, warning)
+		}
+	}
+
+	return status
+}
+
+// runPacket checks packet checksums of every file in conf, and returns the
+// exit status.
+func runPacket(conf *CksumConfigure) int {
+	if conf.Packet != "raw" && conf.Packet != "pcap" {
+		fmt.Printf("Error: unknown packet format '%s'\n", conf.Packet)
+		return 1
+	}
+
+	status := 0
+	for _, name := range conf.Files {
+		file, err := openFile(name)
 		if err != nil {
 			fmt.Printf("Error: %s\n", err)
+			status = 1
 			continue
 		}
 
-		defer file.Close()
-		blocks := 0
+		ok, err := checkPacketFile(file, os.Stdout, name, conf.Packet == "pcap")
+		file.Close()
+		if err != nil {
+			fmt.Printf("Error: %s: %s\n", name, err)
+			status = 1
+		}
+
+		if !ok {
+			status = 1
+		}
+	}
+
+	return status
+}
 
-		checksum.Reset()
-		bufSize := checksum.BlockSize()
-		buf := make([]byte, bufSize)
-		for {
-			length, err := file.Read(buf)
-			if err != nil {
-				if !errors.Is(err, io.EOF) {
-					fmt.Printf("Error: %s\n", err)
-				}
-				break
-			}
+// runBlocks prints the checksum, offset and length of every block of every
+// file in conf, and returns the exit status.
+func runBlocks(conf *CksumConfigure) int {
+	algo, err := LookupAlgorithm(conf.Algorithm)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return 1
+	}
 
-			blocks += 1
-			_ = checksum.Update(buf[:length])
+	status := 0
+	checksum := algo.New()
+	for _, name := range conf.Files {
+		file, err := openFile(name)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			status = 1
+			continue
 		}
 
-		sum := checksum.Checksum()
-		checkResult := ""
-		if toCheck {
-			if sum == *expect {
-				checkResult = " [correct]"
-			} else {
-				checkResult = " [wrong]"
-			}
+		err = ReadBlocks(file, conf.Blocks, func(offset int, block []byte) error {
+			checksum.Reset()
+			_ = checksum.Update(block)
+			fmt.Printf("%x %d %d %s\n", checksum.Sum(nil), offset, len(block), name)
+			return nil
+		})
+		file.Close()
+
+		if err != nil {
+			fmt.Printf("Error: %s: %s\n", name, err)
+			status = 1
 		}
+	}
+
+	return status
+}
+
+// diffFiles matches the blocks of file oldName in file newName, and writes
+// the ranges of newName to out. It returns true if the files are the same.
+func diffFiles(out io.Writer, oldName string, newName string, size int, checksum RollingChecksum) (bool, error) {
+	file, err := openFile(oldName)
+	if err != nil {
+		return false, err
+	}
+
+	signatures, err := BlockSignatures(file, size, checksum)
+	file.Close()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", oldName, err)
+	}
+
+	file, err = openFile(newName)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	matches, err := DiffBlocks(signatures, file, size, checksum)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", newName, err)
+	}
 
-		switch checksum.Size() {
-		case 2:
-			fmt.Printf("%d 0x%04x %d %s%s\n", sum, sum, blocks, arg, checkResult)
-		case 4:
-			fmt.Printf("%d 0x%08x %d %s%s\n", sum, sum, blocks, arg, checkResult)
-		case 8:
-			fmt.Printf("%d 0x%016x %d %s%s\n", sum, sum, blocks, arg, checkResult)
+	// a window prefers the old block at its own offset, so the files are
+	// the same if every block matches at its own offset and none is removed
+	differ, moved := 0, 0
+	for _, m := range matches {
+		if !m.Matched() {
+			differ += m.Length
+			fmt.Fprintf(out, "%d %d differ\n", m.Offset, m.Length)
+			continue
+		}
+
+		if m.Source != m.Offset {
+			moved += m.Length
+		}
+		fmt.Fprintf(out, "%d %d match %d\n", m.Offset, m.Length, m.Source)
+	}
+
+	// removed lines give the offset and length in the old file
+	removed := UnmatchedBlocks(signatures, matches)
+	for _, r := range removed {
+		differ += r.Length
+		fmt.Fprintf(out, "%d %d removed\n", r.Offset, r.Length)
+	}
+
+	fmt.Fprintf(out, "%d of %d blocks of %s found in %s, %d bytes differ, %d bytes moved\n",
+		len(signatures)-len(removed), len(signatures), oldName, newName, differ, moved)
+	same := differ == 0 && moved == 0
+	return same, nil
+}
+
+// runDiff compares the two files of conf by blocks, and returns the exit
+// status.
+func runDiff(conf *CksumConfigure) int {
+	if len(conf.Files) != 2 {
+		fmt.Printf("Error: -diff needs two files\n")
+		return 1
+	}
+
+	size := conf.Blocks
+	if size == 0 {
+		size = DEFAULT_DIFF_BLOCK_SIZE
+	}
+
+	algo, err := LookupAlgorithm(conf.Algorithm)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return 1
+	}
+
+	checksum, ok := algo.New().(RollingChecksum)
+	if !ok {
+		fmt.Printf("Error: %s is not a rolling checksum\n", algo.Name)
+		return 1
+	}
+
+	same, err := diffFiles(os.Stdout, conf.Files[0], conf.Files[1], size, checksum)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return 1
+	}
+
+	if !same {
+		return 1
+	}
+
+	return 0
+}
+
+// run checksums every file in conf, and returns the exit status.
+func run(conf *CksumConfigure) int {
+	if conf.List {
+		if err := ListAlgorithms(os.Stdout); err != nil {
+			fmt.Printf("Error: %s\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if conf.Verify {
+		return runVerify(conf)
+	}
+
+	if conf.Packet != "" {
+		return runPacket(conf)
+	}
+
+	if conf.Diff {
+		return runDiff(conf)
+	}
+
+	if conf.Blocks != 0 {
+		return runBlocks(conf)
+	}
+
+	algos, err := ParseAlgorithms(conf.Algorithm)
+	if err == nil && len(algos) > 1 && (conf.ToCheck || conf.Manifest) {
+		err = errors.New("-check and -manifest only work with one algorithm")
+	}
+
+	var output ResultWriter
+	if err == nil {
+		output, err = NewResultWriter(conf.Format, os.Stdout, conf, algos)
+	}
+
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return 1
+	}
+
+	status := 0
+	files := make([]string, 0, len(conf.Files))
+	for _, name := range conf.Files {
+		info, err := os.Stat(name)
+		if name == "-" || err != nil || !info.IsDir() {
+			files = append(files, name)
+			continue
+		}
+
+		if !conf.Recursive {
+			fmt.Printf("Error: %s is a directory\n", name)
+			status = 1
+			continue
+		}
+
+		found, err := WalkFiles(name, conf.Include, conf.Exclude)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			status = 1
+		}
+
+		files = append(files, found...)
+	}
+
+	newChecksums := func() []Checksum {
+		checksums := make([]Checksum, len(algos))
+		for i, a := range algos {
+			checksums[i] = a.New()
+		}
+		return checksums
+	}
+
+	ChecksumFiles(files, newChecksums, conf.Jobs, func(r FileResult) {
+		if r.Err != nil {
+			status = 1
+		}
+
+		if err := output.WriteResult(r); err != nil {
+			fmt.Printf("Error: %s\n", err)
+			status = 1
 		}
+	})
+
+	if err := output.Close(); err != nil {
+		fmt.Printf("Error: %s\n", err)
+		status = 1
+	}
+
+	return status
+}
+
+func main() {
+	conf := &CksumConfigure{}
+	initFlags(conf)
+	flag.Usage = usage
+	flag.Parse()
+
+	algorithmSet := false
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "check":
+			conf.ToCheck = true
+		case "a":
+			algorithmSet = true
+		}
+	})
+
+	// the algorithm of -diff has to roll, and that of GNU format manifest
+	// lines is found by the digest length
+	if conf.Diff && !algorithmSet {
+		conf.Algorithm = DEFAULT_DIFF_ALGORITHM
+	}
+
+	if conf.Verify && !algorithmSet {
+		conf.Algorithm = ""
 	}
+
+	conf.Files = []string{"-"}
+	if flag.NArg() > 0 {
+		conf.Files = flag.Args()
+	}
+
+	os.Exit(run(conf))
 }
@@ -0,0 +1,192 @@
+package main
+
+import "strings"
+
+// CRCModel is a CRC algorithm in the Rocksoft model. Check is the CRC of the
+// ASCII string "123456789".
+type CRCModel struct {
+	Name    string
+	Aliases []string
+	Width   int
+	Poly    uint64
+	Init    uint64
+	RefIn   bool
+	RefOut  bool
+	XorOut  uint64
+	Check   uint64
+}
+
+// CRC_MODELS are presets taken from the CRC RevEng catalogue,
+// https://reveng.sourceforge.io/crc-catalogue/
+var CRC_MODELS = []CRCModel{
+	{"CRC-5/USB", nil, 5, 0x05, 0x1f, true, true, 0x1f, 0x19},
+	{"CRC-8/SMBUS", []string{"CRC-8"}, 8, 0x07, 0x00, false, false, 0x00, 0xf4},
+	{"CRC-8/MAXIM-DOW", []string{"CRC-8/MAXIM", "DOW-CRC"}, 8, 0x31, 0x00, true, true, 0x00, 0xa1},
+	{"CRC-8/AUTOSAR", nil, 8, 0x2f, 0xff, false, false, 0xff, 0xdf},
+	{"CRC-16/ARC", []string{"CRC-16", "CRC-16/LHA"}, 16, 0x8005, 0x0000, true, true, 0x0000, 0xbb3d},
+	{"CRC-16/IBM-3740", []string{"CRC-16/CCITT-FALSE", "CRC-16/AUTOSAR"}, 16, 0x1021, 0xffff, false, false, 0x0000, 0x29b1},
+	{"CRC-16/KERMIT", []string{"CRC-16/CCITT", "CRC-16/CCITT-TRUE"}, 16, 0x1021, 0x0000, true, true, 0x0000, 0x2189},
+	{"CRC-16/XMODEM", []string{"CRC-16/ACORN", "CRC-16/LTE"}, 16, 0x1021, 0x0000, false, false, 0x0000, 0x31c3},
+	{"CRC-16/GENIBUS", []string{"CRC-16/DARC", "CRC-16/EPC"}, 16, 0x1021, 0xffff, false, false, 0xffff, 0xd64e},
+	{"CRC-16/IBM-SDLC", []string{"CRC-16/X-25", "CRC-16/ISO-HDLC", "X-25"}, 16, 0x1021, 0xffff, true, true, 0xffff, 0x906e},
+	{"CRC-16/MCRF4XX", nil, 16, 0x1021, 0xffff, true, true, 0x0000, 0x6f91},
+	{"CRC-16/MODBUS", []string{"MODBUS"}, 16, 0x8005, 0xffff, true, true, 0x0000, 0x4b37},
+	{"CRC-16/USB", nil, 16, 0x8005, 0xffff, true, true, 0xffff, 0xb4c8},
+	{"CRC-24/OPENPGP", []string{"CRC-24"}, 24, 0x864cfb, 0xb704ce, false, false, 0x000000, 0x21cf02},
+	{"CRC-32/ISO-HDLC", []string{"CRC-32", "CRC32", "CRC-32/ADCCP", "PKZIP"}, 32, 0x04c11db7, 0xffffffff, true, true, 0xffffffff, 0xcbf43926},
+	{"CRC-32/ISCSI", []string{"CRC-32C", "CRC32C", "CRC-32/CASTAGNOLI"}, 32, 0x1edc6f41, 0xffffffff, true, true, 0xffffffff, 0xe3069283},
+	{"CRC-32/BZIP2", []string{"CRC-32/AAL5"}, 32, 0x04c11db7, 0xffffffff, false, false, 0xffffffff, 0xfc891918},
+	{"CRC-32/CKSUM", []string{"CRC-32/POSIX"}, 32, 0x04c11db7, 0x00000000, false, false, 0xffffffff, 0x765e7680},
+	{"CRC-32/MPEG-2", nil, 32, 0x04c11db7, 0xffffffff, false, false, 0x00000000, 0x0376e6e7},
+	{"CRC-64/ECMA-182", []string{"CRC-64"}, 64, 0x42f0e1eba9ea3693, 0, false, false, 0, 0x6c40df5f0b497347},
+	{"CRC-64/XZ", []string{"CRC-64/GO-ECMA"}, 64, 0x42f0e1eba9ea3693, ^uint64(0), true, true, ^uint64(0), 0x995dc9bbdf1939fa},
+	{"CRC-64/GO-ISO", nil, 64, 0x1b, ^uint64(0), true, true, ^uint64(0), 0xb90956c775a41001},
+}
+
+// LookupCRCModel finds a model in CRC_MODELS by its name or alias, case
+// insensitive.
+func LookupCRCModel(name string) (CRCModel, bool) {
+	for _, m := range CRC_MODELS {
+		if strings.EqualFold(m.Name, name) {
+			return m, true
+		}
+
+		for _, alias := range m.Aliases {
+			if strings.EqualFold(alias, name) {
+				return m, true
+			}
+		}
+	}
+
+	return CRCModel{}, false
+}
+
+func reflectBits(v uint64, width int) uint64 {
+	r := uint64(0)
+	for i := 0; i < width; i++ {
+		r = (r << 1) | (v & 1)
+		v >>= 1
+	}
+
+	return r
+}
+
+// CRC is a table-driven CRC of any model up to 64 bits. The register of a
+// reflected model is kept reflected and right aligned, otherwise it is left
+// aligned to the top of 64 bits, so the same byte-wise update works for any
+// width.
+type CRC struct {
+	model CRCModel
+	table []uint64
+	crc   uint64
+}
+
+func NewCRC(model CRCModel) *CRC {
+	c := &CRC{
+		model: model,
+		table: make([]uint64, 256),
+	}
+
+	if model.RefIn {
+		poly := reflectBits(model.Poly, model.Width)
+		for i := range c.table {
+			crc := uint64(i)
+			for j := 0; j < 8; j++ {
+				if crc&1 != 0 {
+					crc = (crc >> 1) ^ poly
+				} else {
+					crc >>= 1
+				}
+			}
+
+			c.table[i] = crc
+		}
+
+	} else {
+		poly := model.Poly << (64 - model.Width)
+		for i := range c.table {
+			crc := uint64(i) << 56
+			for j := 0; j < 8; j++ {
+				if crc&(1<<63) != 0 {
+					crc = (crc << 1) ^ poly
+				} else {
+					crc <<= 1
+				}
+			}
+
+			c.table[i] = crc
+		}
+	}
+
+	c.Reset()
+	return c
+}
+
+// Model returns the model of c.
+func (c *CRC) Model() CRCModel {
+	return c.model
+}
+
+func (c *CRC) Reset() {
+	if c.model.RefIn {
+		c.crc = reflectBits(c.model.Init, c.model.Width)
+	} else {
+		c.crc = c.model.Init << (64 - c.model.Width)
+	}
+}
+
+func (c *CRC) BlockSize() int {
+	return 1024
+}
+
+func (c *CRC) Size() int {
+	return (c.model.Width + 7) / 8
+}
+
+func (c *CRC) Update(data []byte) error {
+	crc := c.crc
+	if c.model.RefIn {
+		for _, b := range data {
+			crc = (crc >> 8) ^ c.table[byte(crc)^b]
+		}
+
+	} else {
+		for _, b := range data {
+			crc = (crc << 8) ^ c.table[byte(crc>>56)^b]
+		}
+	}
+
+	c.crc = crc
+	return nil
+}
+
+func (c *CRC) Checksum() uint64 {
+	w := c.model.Width
+	crc := c.crc
+	if c.model.RefIn {
+		if !c.model.RefOut {
+			crc = reflectBits(crc, w)
+		}
+
+	} else {
+		crc >>= 64 - w
+		if c.model.RefOut {
+			crc = reflectBits(crc, w)
+		}
+	}
+
+	mask := ^uint64(0) >> (64 - w)
+	return (crc ^ c.model.XorOut) & mask
+}
+
+func (c *CRC) Sum(b []byte) []byte {
+	return appendChecksum(b, c.Checksum(), c.Size())
+}
+
+func (c *CRC) Write(data []byte) (int, error) {
+	return len(data), c.Update(data)
+}
+
+func (c *CRC) Sum64() uint64 {
+	return c.Checksum()
+}
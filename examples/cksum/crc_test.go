@@ -0,0 +1,68 @@
+package main
+
+import (
+	"hash/crc32"
+	"hash/crc64"
+	"testing"
+)
+
+func TestCRCModelsCheck(t *testing.T) {
+	data := []byte("123456789")
+	for _, m := range CRC_MODELS {
+		c := NewCRC(m)
+		_ = c.Update(data[:4])
+		_ = c.Update(data[4:])
+		if got := c.Checksum(); got != m.Check {
+			t.Errorf("%s: got 0x%x; expected 0x%x", m.Name, got, m.Check)
+		}
+
+		c.Reset()
+		_ = c.Update(data)
+		if got := c.Checksum(); got != m.Check {
+			t.Errorf("%s after reset: got 0x%x; expected 0x%x", m.Name, got, m.Check)
+		}
+	}
+}
+
+func TestCRCCompareStdlib(t *testing.T) {
+	data := make([]byte, 3000)
+	for i := range data {
+		data[i] = byte(i*7 + i>>8)
+	}
+
+	cases := []struct {
+		name string
+		exp  uint64
+	}{
+		{"crc-32", uint64(crc32.ChecksumIEEE(data))},
+		{"crc-32c", uint64(crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli)))},
+		{"crc-64/xz", crc64.Checksum(data, crc64.MakeTable(crc64.ECMA))},
+		{"crc-64/go-iso", crc64.Checksum(data, crc64.MakeTable(crc64.ISO))},
+	}
+
+	for _, cc := range cases {
+		c := NewChecksum(cc.name)
+		if c == nil {
+			t.Fatalf("algorithm %s not found", cc.name)
+		}
+
+		_ = c.Update(data)
+		if got := c.Checksum(); got != cc.exp {
+			t.Errorf("%s: got 0x%x; expected 0x%x", cc.name, got, cc.exp)
+		}
+	}
+}
+
+func TestCRCPosixMatchesCksumModel(t *testing.T) {
+	// POSIX cksum is CRC-32/CKSUM with the length appended
+	model, _ := LookupCRCModel("CRC-32/CKSUM")
+	c := NewCRC(model)
+	_ = c.Update([]byte("123456789"))
+	_ = c.Update([]byte{9})
+
+	p := NewPosixChecksum()
+	_ = p.Update([]byte("123456789"))
+	if c.Checksum() != p.Checksum() {
+		t.Errorf("got %d; expected %d", c.Checksum(), p.Checksum())
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"hash/fnv"
+)
+
+// RegisterHash registers any hash.Hash as a checksum algorithm. A
+// hash.Hash32 or hash.Hash64 keeps its Sum32 or Sum64.
+func RegisterHash(name string, description string, newHash func() hash.Hash, aliases ...string) error {
+	return Register(Algorithm{
+		Name:        name,
+		Aliases:     aliases,
+		Description: description,
+		Size:        newHash().Size(),
+		New:         func() Checksum { return newHashChecksum(newHash()) },
+	})
+}
+
+func mustRegisterHash(name string, description string, newHash func() hash.Hash, aliases ...string) {
+	if err := RegisterHash(name, description, newHash, aliases...); err != nil {
+		panic(err)
+	}
+}
+
+func init() {
+	mustRegisterHash("md5", "MD5 of RFC 1321", md5.New)
+	mustRegisterHash("sha1", "SHA-1 of FIPS 180-4", sha1.New, "sha-1")
+	mustRegisterHash("sha224", "SHA-224 of FIPS 180-4", sha256.New224, "sha-224")
+	mustRegisterHash("sha256", "SHA-256 of FIPS 180-4", sha256.New, "sha-256")
+	mustRegisterHash("sha384", "SHA-384 of FIPS 180-4", sha512.New384, "sha-384")
+	mustRegisterHash("sha512", "SHA-512 of FIPS 180-4", sha512.New, "sha-512")
+	mustRegisterHash("sha512-256", "SHA-512/256 of FIPS 180-4", sha512.New512_256, "sha-512/256")
+	mustRegisterHash("sha3-224", "SHA3-224 of FIPS 202", func() hash.Hash { return NewSHA3(28) })
+	mustRegisterHash("sha3-256", "SHA3-256 of FIPS 202", func() hash.Hash { return NewSHA3(32) })
+	mustRegisterHash("sha3-384", "SHA3-384 of FIPS 202", func() hash.Hash { return NewSHA3(48) })
+	mustRegisterHash("sha3-512", "SHA3-512 of FIPS 202", func() hash.Hash { return NewSHA3(64) })
+	mustRegisterHash("blake2b-256", "BLAKE2b of RFC 7693, 256 bits", func() hash.Hash { return NewBLAKE2b(32) })
+	mustRegisterHash("blake2b-512", "BLAKE2b of RFC 7693, 512 bits", func() hash.Hash { return NewBLAKE2b(64) }, "blake2b")
+	mustRegisterHash("blake2s-256", "BLAKE2s of RFC 7693, 256 bits", func() hash.Hash { return NewBLAKE2s(32) }, "blake2s")
+	mustRegisterHash("fnv32", "FNV-1, 32 bits", func() hash.Hash { return fnv.New32() })
+	mustRegisterHash("fnv32a", "FNV-1a, 32 bits", func() hash.Hash { return fnv.New32a() })
+	mustRegisterHash("fnv64", "FNV-1, 64 bits", func() hash.Hash { return fnv.New64() })
+	mustRegisterHash("fnv64a", "FNV-1a, 64 bits", func() hash.Hash { return fnv.New64a() })
+	mustRegisterHash("fnv128a", "FNV-1a, 128 bits", fnv.New128a)
+}
+
+// DigestChecksum adapts a hash.Hash to Checksum. Checksum returns the first
+// 8 bytes of the digest, or the whole digest if it is shorter, as a big
+// endian number.
+type DigestChecksum struct {
+	hash hash.Hash
+}
+
+func NewDigestChecksum(h hash.Hash) *DigestChecksum {
+	return &DigestChecksum{
+		hash: h,
+	}
+}
+
+func (c *DigestChecksum) Reset() {
+	c.hash.Reset()
+}
+
+func (c *DigestChecksum) BlockSize() int {
+	return c.hash.BlockSize()
+}
+
+func (c *DigestChecksum) Size() int {
+	return c.hash.Size()
+}
+
+func (c *DigestChecksum) Update(data []byte) error {
+	_, err := c.hash.Write(data)
+	return err
+}
+
+func (c *DigestChecksum) Write(data []byte) (int, error) {
+	return c.hash.Write(data)
+}
+
+func (c *DigestChecksum) Checksum() uint64 {
+	sum := uint64(0)
+	digest := c.hash.Sum(nil)
+	for i := 0; i < len(digest) && i < 8; i++ {
+		sum = (sum << 8) | uint64(digest[i])
+	}
+
+	return sum
+}
+
+func (c *DigestChecksum) Sum(b []byte) []byte {
+	return c.hash.Sum(b)
+}
+
+// digest32Checksum is a DigestChecksum of a hash.Hash32, which is also a
+// hash.Hash64 as the other checksums of 4 bytes.
+type digest32Checksum struct {
+	*DigestChecksum
+	hash32 hash.Hash32
+}
+
+func (c digest32Checksum) Sum32() uint32 {
+	return c.hash32.Sum32()
+}
+
+func (c digest32Checksum) Sum64() uint64 {
+	return uint64(c.hash32.Sum32())
+}
+
+// digest64Checksum is a DigestChecksum of a hash.Hash64.
+type digest64Checksum struct {
+	*DigestChecksum
+	hash64 hash.Hash64
+}
+
+func (c digest64Checksum) Sum64() uint64 {
+	return c.hash64.Sum64()
+}
+
+// newHashChecksum adapts h to Checksum, and passes Sum32 or Sum64 through
+// if h has them.
+func newHashChecksum(h hash.Hash) Checksum {
+	c := NewDigestChecksum(h)
+	switch h := h.(type) {
+	case hash.Hash32:
+		return digest32Checksum{c, h}
+	case hash.Hash64:
+		return digest64Checksum{c, h}
+	default:
+		return c
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/hex"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestDigestVectors(t *testing.T) {
+	long := strings.Repeat("a", 200)
+	cases := []struct {
+		algo string
+		data string
+		exp  string
+	}{
+		{"md5", "abc", "900150983cd24fb0d6963f7d28e17f72"},
+		{"sha1", "abc", "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{"sha256", "abc", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"sha3-224", "", "6b4e03423667dbb73b6e15454f0eb1abd4597f9a1b078e3f5b5a6bc7"},
+		{"sha3-256", "", "a7ffc6f8bf1ed76651c14756a061d662f580ff4de43b49fa82d80a4b80f8434a"},
+		{"sha3-256", "abc", "3a985da74fe225b2045c172d6bd390bd855f086e3e9d525b46bfe24511431532"},
+		{"sha3-256", long, "cce34485baf2bf2aca99b94833892a4f52896d3d153f7b840cc4f9fe695f1387"},
+		{"sha3-384", "abc", "ec01498288516fc926459f58e2c6ad8df9b473cb0fc08c2596da7cf0e49be4b298d88cea927ac7f539f1edf228376d25"},
+		{"sha3-512", "abc", "b751850b1a57168a5693cd924b6b096e08f621827444f70d884f5d0240d2712e10e116e9192af3c91a7ec57647e3934057340b4cf408d5a56592f8274eec53f0"},
+		{"blake2b-256", "", "0e5751c026e543b2e8ab2eb06099daa1d1e5df47778f7787faab45cdf12fe3a8"},
+		{"blake2b-256", long, "6b6e59aaf00eb730cf93de53560846722184bbd92f8368c21ffa95380c2f9fe6"},
+		{"blake2b-512", "abc", "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923"},
+		{"blake2s-256", "", "69217a3079908094e11121d042354a7c1f55b6482ca1a51e1b250dfd1ed0eef9"},
+		{"blake2s-256", "abc", "508c5e8c327c14e2e1a72ba34eeb452f37458b209ed63a294d999b4c86675982"},
+		{"blake2s-256", long, "2b033f9f5ba9cf20671da79e492f41545e673b562603945ffed09662fd92321a"},
+	}
+
+	r := rand.New(rand.NewSource(3))
+	for _, cc := range cases {
+		c := NewChecksum(cc.algo)
+		if c == nil {
+			t.Fatalf("algorithm %s not found", cc.algo)
+		}
+
+		_ = c.Update([]byte(cc.data))
+		if got := hex.EncodeToString(c.Sum(nil)); got != cc.exp {
+			t.Errorf("%s(%d bytes): got %s; expected %s", cc.algo, len(cc.data), got, cc.exp)
+		}
+
+		c.Reset()
+		updateInChunks(c, []byte(cc.data), r)
+		if got := hex.EncodeToString(c.Sum(nil)); got != cc.exp {
+			t.Errorf("%s(%d bytes) in chunks: got %s; expected %s", cc.algo, len(cc.data), got, cc.exp)
+		}
+	}
+}
+
+func TestChecksumSum(t *testing.T) {
+	c := NewChecksum("crc-32")
+	_ = c.Update([]byte("123456789"))
+	got := hex.EncodeToString(c.Sum([]byte{0xff}))
+	if got != "ffcbf43926" {
+		t.Errorf("got %s; expected ffcbf43926", got)
+	}
+
+	if !matchChecksum(c, "0xcbf43926") || !matchChecksum(c, "CBF43926") || matchChecksum(c, "0") {
+		t.Errorf("matchChecksum failed")
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+const ADLER32_MOD = 65521
+
+// Adler32Checksum is the Adler-32 checksum of zlib, RFC 1950. It is also a
+// RollingChecksum over the data written since reset.
+type Adler32Checksum struct {
+	a      uint32
+	b      uint32
+	length uint32
+}
+
+func NewAdler32Checksum() *Adler32Checksum {
+	c := &Adler32Checksum{}
+	c.Reset()
+
+	return c
+}
+
+func (c *Adler32Checksum) Reset() {
+	c.a = 1
+	c.b = 0
+	c.length = 0
+}
+
+func (c *Adler32Checksum) BlockSize() int {
+	return 1024
+}
+
+func (c *Adler32Checksum) Size() int {
+	return 4
+}
+
+func (c *Adler32Checksum) Update(data []byte) error {
+	c.length = uint32((uint64(c.length) + uint64(len(data))) % ADLER32_MOD)
+	a, b := c.a, c.b
+	for len(data) > 0 {
+		// 5552 is the largest n that b can not overflow before reducing
+		n := len(data)
+		if n > 5552 {
+			n = 5552
+		}
+
+		for _, x := range data[:n] {
+			a += uint32(x)
+			b += a
+		}
+
+		a %= ADLER32_MOD
+		b %= ADLER32_MOD
+		data = data[n:]
+	}
+
+	c.a, c.b = a, b
+	return nil
+}
+
+// Roll removes out from the start of the window and appends in. b counts out
+// once for each byte of the window, and gains the new a.
+func (c *Adler32Checksum) Roll(out byte, in byte) {
+	c.a = (c.a + ADLER32_MOD - uint32(out) + uint32(in)) % ADLER32_MOD
+	c.b = (c.b + ADLER32_MOD - c.length*uint32(out)%ADLER32_MOD + c.a + ADLER32_MOD - 1) % ADLER32_MOD
+}
+
+func (c *Adler32Checksum) Checksum() uint64 {
+	return uint64(c.b)<<16 | uint64(c.a)
+}
+
+func (c *Adler32Checksum) Sum(b []byte) []byte {
+	return appendChecksum(b, c.Checksum(), c.Size())
+}
+
+func (c *Adler32Checksum) Write(data []byte) (int, error) {
+	return len(data), c.Update(data)
+}
+
+func (c *Adler32Checksum) Sum32() uint32 {
+	return uint32(c.Checksum())
+}
+
+func (c *Adler32Checksum) Sum64() uint64 {
+	return c.Checksum()
+}
+
+// FletcherChecksum is the Fletcher checksum over little endian words of
+// wordSize bytes, modulo 2^(8*wordSize)-1. The last word is padded with
+// zeros, and bytes of an incomplete word are kept between updates.
+type FletcherChecksum struct {
+	wordSize int
+	modulus  uint64
+	sum1     uint64
+	sum2     uint64
+	pending  []byte
+}
+
+func newFletcherChecksum(wordSize int) *FletcherChecksum {
+	c := &FletcherChecksum{
+		wordSize: wordSize,
+		modulus:  (uint64(1) << (8 * wordSize)) - 1,
+		pending:  make([]byte, 0, wordSize),
+	}
+	c.Reset()
+
+	return c
+}
+
+func NewFletcher16Checksum() *FletcherChecksum {
+	return newFletcherChecksum(1)
+}
+
+func NewFletcher32Checksum() *FletcherChecksum {
+	return newFletcherChecksum(2)
+}
+
+func NewFletcher64Checksum() *FletcherChecksum {
+	return newFletcherChecksum(4)
+}
+
+func (c *FletcherChecksum) Reset() {
+	c.sum1 = 0
+	c.sum2 = 0
+	c.pending = c.pending[:0]
+}
+
+func (c *FletcherChecksum) BlockSize() int {
+	return 1024
+}
+
+func (c *FletcherChecksum) Size() int {
+	return 2 * c.wordSize
+}
+
+func (c *FletcherChecksum) word(data []byte) uint64 {
+	w := uint64(0)
+	for i := len(data) - 1; i >= 0; i-- {
+		w = (w << 8) | uint64(data[i])
+	}
+
+	return w
+}
+
+func (c *FletcherChecksum) add(sum1 uint64, sum2 uint64, w uint64) (uint64, uint64) {
+	sum1 = (sum1 + w) % c.modulus
+	sum2 = (sum2 + sum1) % c.modulus
+	return sum1, sum2
+}
+
+func (c *FletcherChecksum) Update(data []byte) error {
+	if len(c.pending) > 0 {
+		n := c.wordSize - len(c.pending)
+		if n > len(data) {
+			n = len(data)
+		}
+
+		c.pending = append(c.pending, data[:n]...)
+		data = data[n:]
+		if len(c.pending) < c.wordSize {
+			return nil
+		}
+
+		c.sum1, c.sum2 = c.add(c.sum1, c.sum2, c.word(c.pending))
+		c.pending = c.pending[:0]
+	}
+
+	sum1, sum2 := c.sum1, c.sum2
+	for len(data) >= c.wordSize {
+		sum1, sum2 = c.add(sum1, sum2, c.word(data[:c.wordSize]))
+		data = data[c.wordSize:]
+	}
+
+	c.sum1, c.sum2 = sum1, sum2
+	c.pending = append(c.pending, data...)
+	return nil
+}
+
+func (c *FletcherChecksum) Checksum() uint64 {
+	sum1, sum2 := c.sum1, c.sum2
+	if len(c.pending) > 0 {
+		sum1, sum2 = c.add(sum1, sum2, c.word(c.pending))
+	}
+
+	return sum2<<(8*c.wordSize) | sum1
+}
+
+func (c *FletcherChecksum) Sum(b []byte) []byte {
+	return appendChecksum(b, c.Checksum(), c.Size())
+}
+
+func (c *FletcherChecksum) Write(data []byte) (int, error) {
+	return len(data), c.Update(data)
+}
+
+func (c *FletcherChecksum) Sum64() uint64 {
+	return c.Checksum()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"hash/adler32"
+	"math/rand"
+	"testing"
+)
+
+// updateInChunks feeds data to c in chunks of random sizes.
+func updateInChunks(c Checksum, data []byte, r *rand.Rand) {
+	for len(data) > 0 {
+		n := r.Intn(len(data)) + 1
+		if n > 7 && r.Intn(2) == 0 {
+			n = r.Intn(7) + 1
+		}
+
+		_ = c.Update(data[:n])
+		data = data[n:]
+	}
+}
+
+func TestFletcherVectors(t *testing.T) {
+	// test vectors in https://en.wikipedia.org/wiki/Fletcher%27s_checksum
+	cases := []struct {
+		algo string
+		data string
+		exp  uint64
+	}{
+		{"fletcher16", "abcde", 0xc8f0},
+		{"fletcher16", "abcdef", 0x2057},
+		{"fletcher16", "abcdefgh", 0x0627},
+		{"fletcher32", "abcde", 0xf04fc729},
+		{"fletcher32", "abcdef", 0x56502d2a},
+		{"fletcher32", "abcdefgh", 0xebe19591},
+		{"fletcher64", "abcde", 0xc8c6c527646362c6},
+		{"fletcher64", "abcdef", 0xc8c72b276463c8c6},
+		{"fletcher64", "abcdefgh", 0x312e2b28cccac8c6},
+		{"adler32", "Wikipedia", 0x11e60398},
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for _, cc := range cases {
+		c := NewChecksum(cc.algo)
+		_ = c.Update([]byte(cc.data))
+		if got := c.Checksum(); got != cc.exp {
+			t.Errorf("%s(%q): got 0x%x; expected 0x%x", cc.algo, cc.data, got, cc.exp)
+		}
+
+		for i := 0; i < 10; i++ {
+			c.Reset()
+			updateInChunks(c, []byte(cc.data), r)
+			if got := c.Checksum(); got != cc.exp {
+				t.Errorf("%s(%q) in chunks: got 0x%x; expected 0x%x", cc.algo, cc.data, got, cc.exp)
+			}
+		}
+	}
+}
+
+func TestAdditiveChunkBoundaries(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	data := make([]byte, 20000)
+	r.Read(data)
+
+	for _, algo := range []string{"adler32", "fletcher16", "fletcher32", "fletcher64"} {
+		c := NewChecksum(algo)
+		_ = c.Update(data)
+		exp := c.Checksum()
+		if algo == "adler32" && exp != uint64(adler32.Checksum(data)) {
+			t.Errorf("adler32: got 0x%x; expected 0x%x", exp, adler32.Checksum(data))
+		}
+
+		for i := 0; i < 20; i++ {
+			c.Reset()
+			updateInChunks(c, data, r)
+			if got := c.Checksum(); got != exp {
+				t.Errorf("%s in chunks: got 0x%x; expected 0x%x", algo, got, exp)
+			}
+		}
+	}
+}
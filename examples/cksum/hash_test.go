@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"hash"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"strings"
+	"testing"
+)
+
+var (
+	_ hash.Hash32 = (*InternetChecksum)(nil)
+	_ hash.Hash32 = (*BSDChecksum)(nil)
+	_ hash.Hash32 = (*SysVChecksum)(nil)
+	_ hash.Hash32 = (*PosixChecksum)(nil)
+	_ hash.Hash32 = (*Adler32Checksum)(nil)
+	_ hash.Hash64 = (*FletcherChecksum)(nil)
+	_ hash.Hash64 = (*CRC)(nil)
+	_ hash.Hash   = (*DigestChecksum)(nil)
+)
+
+func TestChecksumWriter(t *testing.T) {
+	data := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 1000)
+	algos := []string{"net", "bsd", "sysv", "posix", "adler32", "fletcher32", "crc-32", "sha256", "fnv64a"}
+
+	checksums := make([]Checksum, len(algos))
+	writers := make([]io.Writer, len(algos))
+	for i, algo := range algos {
+		checksums[i] = NewChecksum(algo)
+		writers[i] = checksums[i]
+	}
+
+	if _, err := io.Copy(io.MultiWriter(writers...), strings.NewReader(data)); err != nil {
+		t.Fatalf("copy failed: %s", err)
+	}
+
+	for i, algo := range algos {
+		c := NewChecksum(algo)
+		_ = c.Update([]byte(data))
+		if !bytes.Equal(checksums[i].Sum(nil), c.Sum(nil)) {
+			t.Errorf("%s: got %x; expected %x", algo, checksums[i].Sum(nil), c.Sum(nil))
+		}
+	}
+}
+
+func TestChecksumHash32(t *testing.T) {
+	data := []byte("123456789")
+	h := NewChecksum("crc-32").(hash.Hash32)
+	h.Write(data)
+	if h.Sum32() != crc32.ChecksumIEEE(data) {
+		t.Errorf("got %08x; expected %08x", h.Sum32(), crc32.ChecksumIEEE(data))
+	}
+}
+
+func TestChecksumHash32Size(t *testing.T) {
+	for _, algo := range []string{"net", "posix", "fletcher16", "fletcher32", "crc-16/arc", "crc-32", "fletcher64", "crc-64/xz", "fnv32", "fnv32a", "fnv64"} {
+		c := NewChecksum(algo)
+		if _, ok := c.(hash.Hash64); !ok {
+			t.Errorf("%s: expected a hash.Hash64", algo)
+		}
+
+		if _, ok := c.(hash.Hash32); ok != (c.Size() <= 4) {
+			t.Errorf("%s of %d bytes: got hash.Hash32 %v", algo, c.Size(), ok)
+		}
+	}
+}
+
+func TestDigestSum32(t *testing.T) {
+	data := []byte("123456789")
+	h32 := fnv.New32()
+	h32.Write(data)
+	c := NewChecksum("fnv32").(hash.Hash32)
+	c.Write(data)
+	if c.Sum32() != h32.Sum32() || c.(hash.Hash64).Sum64() != uint64(h32.Sum32()) {
+		t.Errorf("fnv32: got %08x; expected %08x", c.Sum32(), h32.Sum32())
+	}
+
+	h64 := fnv.New64()
+	h64.Write(data)
+	c64 := NewChecksum("fnv64").(hash.Hash64)
+	c64.Write(data)
+	if c64.Sum64() != h64.Sum64() {
+		t.Errorf("fnv64: got %016x; expected %016x", c64.Sum64(), h64.Sum64())
+	}
+}
+
+func TestRegisterHash(t *testing.T) {
+	err := RegisterHash("Test-CRC32", "CRC-32 of hash/crc32", func() hash.Hash { return crc32.NewIEEE() })
+	if err != nil {
+		t.Fatalf("register failed: %s", err)
+	}
+	defer delete(ALGORITHMS, "test-crc32")
+
+	c := NewChecksum("test-crc32")
+	if c == nil {
+		t.Fatalf("registered hash not found")
+	}
+
+	_, _ = c.Write([]byte("123456789"))
+	if c.Size() != 4 || c.Checksum() != 0xcbf43926 {
+		t.Errorf("got size %d, checksum %08x", c.Size(), c.Checksum())
+	}
+}
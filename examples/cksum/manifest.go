@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ManifestEntry is a line of a checksum manifest. Algorithm is empty for
+// GNU format lines, which do not name their algorithm.
+type ManifestEntry struct {
+	Algorithm string
+	Name      string
+	Digest    []byte
+}
+
+// escapeFilename escapes backslashes and newlines as GNU *sum does, and
+// reports whether the name is changed.
+func escapeFilename(name string) (string, bool) {
+	if !strings.ContainsAny(name, "\\\n\r") {
+		return name, false
+	}
+
+	r := strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r")
+	return r.Replace(name), true
+}
+
+func unescapeFilename(name string) (string, error) {
+	buf := make([]byte, 0, len(name))
+	for i := 0; i < len(name); i++ {
+		if name[i] != '\\' {
+			buf = append(buf, name[i])
+			continue
+		}
+
+		i++
+		if i >= len(name) {
+			return "", errors.New("unterminated escape")
+		}
+
+		switch name[i] {
+		case '\\':
+			buf = append(buf, '\\')
+		case 'n':
+			buf = append(buf, '\n')
+		case 'r':
+			buf = append(buf, '\r')
+		default:
+			return "", fmt.Errorf("invalid escape '\\%c'", name[i])
+		}
+	}
+
+	return string(buf), nil
+}
+
+// FormatManifestLine formats a manifest line, in BSD tagged format
+// "ALGO (name) = hex" if tag is true, or GNU format "hex  name" otherwise.
+func FormatManifestLine(algo string, name string, digest []byte, tag bool) string {
+	escaped, changed := escapeFilename(name)
+	prefix := ""
+	if changed {
+		prefix = "\\"
+	}
+
+	if tag {
+		return fmt.Sprintf("%s%s (%s) = %x", prefix, strings.ToUpper(algo), escaped, digest)
+	}
+
+	return fmt.Sprintf("%s%x  %s", prefix, digest, escaped)
+}
+
+func parseHexDigest(s string) ([]byte, error) {
+	if len(s) == 0 || len(s)%2 != 0 {
+		return nil, errors.New("invalid digest length")
+	}
+
+	return hex.DecodeString(s)
+}
+
+// ParseManifestLine parses a line of GNU or BSD tagged format.
+func ParseManifestLine(line string) (ManifestEntry, error) {
+	entry := ManifestEntry{}
+	escaped := strings.HasPrefix(line, "\\")
+	if escaped {
+		line = line[1:]
+	}
+
+	if open := strings.Index(line, " ("); open > 0 && !strings.Contains(line[:open], " ") {
+		end := strings.LastIndex(line, ") = ")
+		if end < open {
+			return entry, errors.New("improperly formatted tagged line")
+		}
+
+		entry.Algorithm = strings.ToLower(line[:open])
+		entry.Name = line[open+2 : end]
+		digest, err := parseHexDigest(line[end+4:])
+		if err != nil {
+			return entry, err
+		}
+		entry.Digest = digest
+
+	} else {
+		sep := strings.IndexByte(line, ' ')
+		if sep < 0 || sep+2 > len(line) || (line[sep+1] != ' ' && line[sep+1] != '*') {
+			return entry, errors.New("improperly formatted line")
+		}
+
+		digest, err := parseHexDigest(line[:sep])
+		if err != nil {
+			return entry, err
+		}
+
+		entry.Digest = digest
+		entry.Name = line[sep+2:]
+	}
+
+	if entry.Name == "" {
+		return entry, errors.New("missing file name")
+	}
+
+	if escaped {
+		name, err := unescapeFilename(entry.Name)
+		if err != nil {
+			return entry, err
+		}
+		entry.Name = name
+	}
+
+	return entry, nil
+}
+
+// DIGEST_LENGTH_ALGORITHMS maps digest lengths in bytes to the algorithms of
+// the GNU *sum tools, to check GNU format lines when no algorithm is given.
+var DIGEST_LENGTH_ALGORITHMS = map[int]string{
+	16: "md5",
+	20: "sha1",
+	28: "sha224",
+	32: "sha256",
+	48: "sha384",
+	64: "sha512",
+}
+
+// VerifyOptions are the GNU *sum -c options. Quiet omits OK lines, Status
+// prints nothing, and Strict fails on improperly formatted lines.
+type VerifyOptions struct {
+	Quiet  bool
+	Status bool
+	Strict bool
+}
+
+// VerifyResult counts the lines of a manifest by their results.
+type VerifyResult struct {
+	Matched    int
+	Failed     int
+	Unreadable int
+	Malformed  int
+}
+
+// OK returns true if every line is verified, and no line is improperly
+// formatted when strict is true.
+func (r VerifyResult) OK(strict bool) bool {
+	if r.Failed > 0 || r.Unreadable > 0 || r.Matched == 0 {
+		return false
+	}
+
+	return !strict || r.Malformed == 0
+}
+
+func plural(n int, singular string, plural string) string {
+	if n == 1 {
+		return singular
+	}
+
+	return plural
+}
+
+// Warnings returns the summary lines of GNU *sum -c for problems found.
+func (r VerifyResult) Warnings() []string {
+	warnings := make([]string, 0)
+	if r.Malformed > 0 {
+		warnings = append(warnings, fmt.Sprintf("WARNING: %d %s improperly formatted",
+			r.Malformed, plural(r.Malformed, "line is", "lines are")))
+	}
+
+	if r.Unreadable > 0 {
+		warnings = append(warnings, fmt.Sprintf("WARNING: %d listed %s could not be read",
+			r.Unreadable, plural(r.Unreadable, "file", "files")))
+	}
+
+	if r.Failed > 0 {
+		warnings = append(warnings, fmt.Sprintf("WARNING: %d computed %s did NOT match",
+			r.Failed, plural(r.Failed, "checksum", "checksums")))
+	}
+
+	return warnings
+}
+
+// VerifyManifest checks every file listed in manifest, and writes
+// "name: OK", "name: FAILED" or "name: FAILED open or read" for each line.
+// GNU format lines are checked with algo, or the algorithm of their digest
+// length in DIGEST_LENGTH_ALGORITHMS if algo is empty, and tagged lines with
+// their own algorithm.
+func VerifyManifest(manifest io.Reader, out io.Writer, algo string, opts VerifyOptions) (VerifyResult, error) {
+	result := VerifyResult{}
+	reader := bufio.NewReader(manifest)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return result, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" {
+			if errVerify := verifyManifestLine(line, out, algo, opts, &result); errVerify != nil {
+				return result, errVerify
+			}
+		}
+
+		if err != nil {
+			return result, nil
+		}
+	}
+}
+
+func verifyManifestLine(line string, out io.Writer, algo string, opts VerifyOptions, result *VerifyResult) error {
+	report := func(format string, args ...interface{}) error {
+		if opts.Status {
+			return nil
+		}
+
+		_, err := fmt.Fprintf(out, format, args...)
+		return err
+	}
+
+	entry, err := ParseManifestLine(line)
+	if err == nil && entry.Algorithm != "" {
+		algo = entry.Algorithm
+	} else if err == nil && algo == "" {
+		algo = DIGEST_LENGTH_ALGORITHMS[len(entry.Digest)]
+	}
+
+	var checksum Checksum
+	if err == nil {
+		checksum = NewChecksum(algo)
+		if checksum == nil || checksum.Size() != len(entry.Digest) {
+			err = fmt.Errorf("digest does not match algorithm %s", algo)
+		}
+	}
+
+	if err != nil {
+		result.Malformed++
+		return nil
+	}
+
+	if _, err := checksumFile(entry.Name, checksum); err != nil {
+		result.Unreadable++
+		return report("%s: FAILED open or read\n", entry.Name)
+	}
+
+	if !bytes.Equal(checksum.Sum(nil), entry.Digest) {
+		result.Failed++
+		return report("%s: FAILED\n", entry.Name)
+	}
+
+	result.Matched++
+	if opts.Quiet {
+		return nil
+	}
+
+	return report("%s: OK\n", entry.Name)
+}
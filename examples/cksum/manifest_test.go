@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManifestLineRoundTrip(t *testing.T) {
+	digest, _ := hex.DecodeString("15e2b0d3c33891ebb0f1ef609ec419420c20e320ce94c65fbc8c3312448eb225")
+	cases := []struct {
+		name string
+		tag  bool
+		exp  string
+	}{
+		{"n.txt", false, "15e2b0d3c33891ebb0f1ef609ec419420c20e320ce94c65fbc8c3312448eb225  n.txt"},
+		{"n.txt", true, "SHA256 (n.txt) = 15e2b0d3c33891ebb0f1ef609ec419420c20e320ce94c65fbc8c3312448eb225"},
+		{"a (1).txt", true, "SHA256 (a (1).txt) = 15e2b0d3c33891ebb0f1ef609ec419420c20e320ce94c65fbc8c3312448eb225"},
+		{"a\nb\\c", false, "\\15e2b0d3c33891ebb0f1ef609ec419420c20e320ce94c65fbc8c3312448eb225  a\\nb\\\\c"},
+	}
+
+	for _, c := range cases {
+		line := FormatManifestLine("sha256", c.name, digest, c.tag)
+		if line != c.exp {
+			t.Errorf("format %q: got %q; expected %q", c.name, line, c.exp)
+		}
+
+		entry, err := ParseManifestLine(line)
+		if err != nil {
+			t.Fatalf("parse %q failed: %s", line, err)
+		}
+
+		if entry.Name != c.name || !bytes.Equal(entry.Digest, digest) {
+			t.Errorf("parse %q: got %q %x", line, entry.Name, entry.Digest)
+		}
+
+		if c.tag && entry.Algorithm != "sha256" {
+			t.Errorf("parse %q: got algorithm %q", line, entry.Algorithm)
+		}
+	}
+}
+
+func TestParseManifestLineInvalid(t *testing.T) {
+	for _, line := range []string{"junk", "abc  file", "zz  file", "0011 file", "0011  ", "SHA256 (file) = xyz"} {
+		if _, err := ParseManifestLine(line); err == nil {
+			t.Errorf("parse %q: expected an error", line)
+		}
+	}
+}
+
+func TestVerifyManifest(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.txt")
+	bad := filepath.Join(dir, "bad.txt")
+	_ = os.WriteFile(good, []byte("123456789"), 0644)
+	_ = os.WriteFile(bad, []byte("changed"), 0644)
+
+	digest := "15e2b0d3c33891ebb0f1ef609ec419420c20e320ce94c65fbc8c3312448eb225"
+	manifest := strings.Join([]string{
+		digest + "  " + good,
+		digest + " *" + bad,
+		digest + "  " + filepath.Join(dir, "missing.txt"),
+		"CRC-32 (" + good + ") = cbf43926",
+		"not a checksum line",
+	}, "\n")
+
+	cases := []struct {
+		opts VerifyOptions
+		exp  string
+		ok   bool
+	}{
+		{VerifyOptions{}, "" +
+			good + ": OK\n" +
+			bad + ": FAILED\n" +
+			filepath.Join(dir, "missing.txt") + ": FAILED open or read\n" +
+			good + ": OK\n", false},
+		{VerifyOptions{Quiet: true}, "" +
+			bad + ": FAILED\n" +
+			filepath.Join(dir, "missing.txt") + ": FAILED open or read\n", false},
+		{VerifyOptions{Status: true}, "", false},
+	}
+
+	for i, c := range cases {
+		out := bytes.NewBuffer(nil)
+		result, err := VerifyManifest(strings.NewReader(manifest), out, "sha256", c.opts)
+		if err != nil {
+			t.Fatalf("case %d: verify failed: %s", i, err)
+		}
+
+		if out.String() != c.exp {
+			t.Errorf("case %d: got\n%s\nexpected\n%s", i, out.String(), c.exp)
+		}
+
+		exp := VerifyResult{Matched: 2, Failed: 1, Unreadable: 1, Malformed: 1}
+		if result != exp {
+			t.Errorf("case %d: got %+v; expected %+v", i, result, exp)
+		}
+
+		if result.OK(false) != c.ok {
+			t.Errorf("case %d: got OK %v", i, result.OK(false))
+		}
+	}
+
+	result, _ := VerifyManifest(strings.NewReader(digest+"  "+good+"\njunk\n"), bytes.NewBuffer(nil), "sha256", VerifyOptions{})
+	if !result.OK(false) || result.OK(true) {
+		t.Errorf("strict mode: got %+v", result)
+	}
+
+	// without an algorithm, GNU format lines are checked by digest length
+	inferred := strings.Join([]string{
+		digest + "  " + good,
+		"25f9e794323b453885f5181f1b624d0b  " + good,
+		"cbf43926  " + good,
+	}, "\n")
+	result, _ = VerifyManifest(strings.NewReader(inferred), bytes.NewBuffer(nil), "", VerifyOptions{})
+	if exp := (VerifyResult{Matched: 2, Malformed: 1}); result != exp {
+		t.Errorf("inferred algorithms: got %+v; expected %+v", result, exp)
+	}
+}
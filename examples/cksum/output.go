@@ -0,0 +1,351 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ResultWriter writes the results of files in an output format.
+type ResultWriter interface {
+	WriteResult(r FileResult) error
+	Close() error
+}
+
+// NewResultWriter returns a writer of format plain, csv, tsv or json for
+// results of algos. The formats csv-wide, tsv-wide and jsonl write one row
+// or line for each file instead of each record.
+func NewResultWriter(format string, out io.Writer, conf *CksumConfigure, algos []*Algorithm) (ResultWriter, error) {
+	switch format {
+	case "plain":
+		return &plainResultWriter{out, conf, algos}, nil
+
+	case "csv", "tsv":
+		writer := csv.NewWriter(out)
+		if format == "tsv" {
+			writer.Comma = '\t'
+		}
+		return &csvResultWriter{writer: writer, conf: conf, algos: algos}, nil
+
+	case "csv-wide", "tsv-wide":
+		writer := csv.NewWriter(out)
+		if format == "tsv-wide" {
+			writer.Comma = '\t'
+		}
+		return &wideResultWriter{writer: writer, algos: algos}, nil
+
+	case "json":
+		return &jsonResultWriter{out: out, conf: conf, algos: algos}, nil
+
+	case "jsonl":
+		return &jsonLinesResultWriter{json.NewEncoder(out), algos}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown output format '%s'", format)
+	}
+}
+
+// plainResultWriter writes a result of one algorithm as formatResult does,
+// and a result of multiple algorithms as one row of hex values followed by
+// the file name. Errors are written as "Error: ..." lines.
+type plainResultWriter struct {
+	out   io.Writer
+	conf  *CksumConfigure
+	algos []*Algorithm
+}
+
+func (w *plainResultWriter) WriteResult(r FileResult) error {
+	if r.Err != nil {
+		_, err := fmt.Fprintf(w.out, "Error: %s\n", r.Err)
+		return err
+	}
+
+	if len(r.Checksums) == 1 {
+		_, err := fmt.Fprintln(w.out, formatResult(w.conf, w.algos[0], r.Checksums[0], r.Name, r.Length))
+		return err
+	}
+
+	// BSD tagged lines name their algorithms, so one line is written for
+	// each algorithm
+	if w.conf.Tag {
+		for i, c := range r.Checksums {
+			line := FormatManifestLine(w.algos[i].Name, r.Name, c.Sum(nil), true)
+			if _, err := fmt.Fprintln(w.out, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	row := ""
+	for _, c := range r.Checksums {
+		row += hex.EncodeToString(c.Sum(nil)) + " "
+	}
+
+	_, err := fmt.Fprintf(w.out, "%s %s\n", row, r.Name)
+	return err
+}
+
+func (w *plainResultWriter) Close() error {
+	return nil
+}
+
+// Record is the checksum of a file by one algorithm. Decimal is empty for
+// digests longer than 8 bytes, Check is "correct" or "wrong" with -check,
+// and the checksum fields are empty if the file can not be read.
+type Record struct {
+	File      string `json:"file"`
+	Algorithm string `json:"algorithm"`
+	Decimal   string `json:"decimal,omitempty"`
+	Hex       string `json:"hex,omitempty"`
+	Bytes     int    `json:"bytes"`
+	Blocks    int    `json:"blocks"`
+	Check     string `json:"check,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+var RECORD_FIELDS = []string{"file", "algorithm", "decimal", "hex", "bytes", "blocks", "check", "error"}
+
+func (r Record) fields() []string {
+	return []string{r.File, r.Algorithm, r.Decimal, r.Hex, strconv.Itoa(r.Bytes), strconv.Itoa(r.Blocks), r.Check, r.Error}
+}
+
+// Summary aggregates the results of all files.
+type Summary struct {
+	Algorithms []string `json:"algorithms"`
+	Files      int      `json:"files"`
+	Bytes      int      `json:"bytes"`
+	Errors     int      `json:"errors"`
+	Correct    int      `json:"correct"`
+	Wrong      int      `json:"wrong"`
+}
+
+// NewSummary returns an empty summary of algos.
+func NewSummary(algos []*Algorithm) Summary {
+	summary := Summary{Algorithms: make([]string, len(algos))}
+	for i, a := range algos {
+		summary.Algorithms[i] = a.Name
+	}
+
+	return summary
+}
+
+// Add counts the records of a file.
+func (s *Summary) Add(records []Record) {
+	s.Files++
+	if len(records) > 0 {
+		s.Bytes += records[0].Bytes
+		if records[0].Error != "" {
+			s.Errors++
+		}
+	}
+
+	for _, r := range records {
+		switch r.Check {
+		case "correct":
+			s.Correct++
+		case "wrong":
+			s.Wrong++
+		}
+	}
+}
+
+// NewRecords returns one record for each algorithm of a result.
+func NewRecords(conf *CksumConfigure, algos []*Algorithm, r FileResult) []Record {
+	records := make([]Record, len(algos))
+	for i, a := range algos {
+		record := Record{File: r.Name, Algorithm: a.Name, Bytes: r.Length}
+		if r.Err != nil {
+			record.Error = r.Err.Error()
+			records[i] = record
+			continue
+		}
+
+		checksum := r.Checksums[i]
+		if checksum.Size() <= 8 {
+			record.Decimal = strconv.FormatUint(checksum.Checksum(), 10)
+		}
+		record.Hex = hex.EncodeToString(checksum.Sum(nil))
+		record.Blocks = countBlocks(checksum, r.Length)
+
+		if conf.ToCheck {
+			record.Check = "wrong"
+			if matchChecksum(checksum, conf.Expect) {
+				record.Check = "correct"
+			}
+		}
+
+		records[i] = record
+	}
+
+	return records
+}
+
+// csvResultWriter writes a header of RECORD_FIELDS, then one row for each
+// record, separated by commas or tabs. The output is a single table, so the
+// summary is only written in json.
+type csvResultWriter struct {
+	writer        *csv.Writer
+	conf          *CksumConfigure
+	algos         []*Algorithm
+	headerWritten bool
+}
+
+func (w *csvResultWriter) writeHeader() error {
+	if w.headerWritten {
+		return nil
+	}
+
+	w.headerWritten = true
+	return w.writer.Write(RECORD_FIELDS)
+}
+
+func (w *csvResultWriter) WriteResult(r FileResult) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+
+	for _, record := range NewRecords(w.conf, w.algos, r) {
+		if err := w.writer.Write(record.fields()); err != nil {
+			return err
+		}
+	}
+
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *csvResultWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type jsonOutput struct {
+	Records []Record `json:"records"`
+	Summary Summary  `json:"summary"`
+}
+
+// jsonResultWriter writes a JSON object of all records and their summary
+// when closed.
+type jsonResultWriter struct {
+	out    io.Writer
+	conf   *CksumConfigure
+	algos  []*Algorithm
+	output *jsonOutput
+}
+
+func (w *jsonResultWriter) init() {
+	if w.output == nil {
+		w.output = &jsonOutput{Records: make([]Record, 0), Summary: NewSummary(w.algos)}
+	}
+}
+
+func (w *jsonResultWriter) WriteResult(r FileResult) error {
+	w.init()
+	records := NewRecords(w.conf, w.algos, r)
+	w.output.Records = append(w.output.Records, records...)
+	w.output.Summary.Add(records)
+	return nil
+}
+
+func (w *jsonResultWriter) Close() error {
+	w.init()
+	encoder := json.NewEncoder(w.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(w.output)
+}
+
+// wideResultWriter writes a header of file, bytes, one column for each
+// algorithm and error, then one row for each file.
+type wideResultWriter struct {
+	writer        *csv.Writer
+	algos         []*Algorithm
+	headerWritten bool
+}
+
+func (w *wideResultWriter) WriteResult(r FileResult) error {
+	if !w.headerWritten {
+		header := []string{"file", "bytes"}
+		for _, a := range w.algos {
+			header = append(header, a.Name)
+		}
+
+		if err := w.writer.Write(append(header, "error")); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+
+	row := []string{r.Name, strconv.Itoa(r.Length)}
+	for i := range w.algos {
+		value := ""
+		if r.Err == nil {
+			value = hex.EncodeToString(r.Checksums[i].Sum(nil))
+		}
+		row = append(row, value)
+	}
+
+	errText := ""
+	if r.Err != nil {
+		errText = r.Err.Error()
+	}
+
+	if err := w.writer.Write(append(row, errText)); err != nil {
+		return err
+	}
+
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *wideResultWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type jsonChecksum struct {
+	Algorithm string `json:"algorithm"`
+	Checksum  string `json:"checksum"`
+}
+
+type jsonResult struct {
+	File      string         `json:"file"`
+	Bytes     int            `json:"bytes"`
+	Checksums []jsonChecksum `json:"checksums,omitempty"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// jsonLinesResultWriter writes one JSON object for each file on a line, with
+// checksums in the order of algorithms.
+type jsonLinesResultWriter struct {
+	encoder *json.Encoder
+	algos   []*Algorithm
+}
+
+func (w *jsonLinesResultWriter) WriteResult(r FileResult) error {
+	result := jsonResult{
+		File:  r.Name,
+		Bytes: r.Length,
+	}
+
+	if r.Err != nil {
+		result.Error = r.Err.Error()
+	} else {
+		for i, a := range w.algos {
+			result.Checksums = append(result.Checksums, jsonChecksum{a.Name, hex.EncodeToString(r.Checksums[i].Sum(nil))})
+		}
+	}
+
+	return w.encoder.Encode(result)
+}
+
+func (w *jsonLinesResultWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testResults(t *testing.T, algos []*Algorithm) []FileResult {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "n.txt")
+	if err := os.WriteFile(name, []byte("123456789"), 0644); err != nil {
+		t.Fatalf("write failed: %s", err)
+	}
+
+	checksums := make([]Checksum, len(algos))
+	for i, a := range algos {
+		checksums[i] = a.New()
+	}
+
+	length, err := checksumFile(name, checksums...)
+	if err != nil {
+		t.Fatalf("checksum failed: %s", err)
+	}
+
+	return []FileResult{
+		{"n.txt", length, checksums, nil},
+		{"missing", 0, nil, errors.New("open missing: no such file")},
+	}
+}
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// checkGolden compares got with testdata/name, or writes it with -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("update %s failed: %s", path, err)
+		}
+		return
+	}
+
+	exp, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden failed: %s", err)
+	}
+
+	if !bytes.Equal(got, exp) {
+		t.Errorf("%s: got\n%s\nexpected\n%s", name, got, exp)
+	}
+}
+
+func TestResultWriters(t *testing.T) {
+	cases := []struct {
+		name string
+		algo string
+		conf CksumConfigure
+	}{
+		{"multi", "net, bsd,crc32,md5", CksumConfigure{}},
+		{"check", "crc32", CksumConfigure{ToCheck: true, Expect: "0xcbf43926"}},
+	}
+
+	for _, c := range cases {
+		algos, err := ParseAlgorithms(c.algo)
+		if err != nil {
+			t.Fatalf("parse failed: %s", err)
+		}
+
+		results := testResults(t, algos)
+		for _, format := range []string{"plain", "csv", "tsv", "json", "csv-wide", "tsv-wide", "jsonl"} {
+			out := bytes.NewBuffer(nil)
+			w, err := NewResultWriter(format, out, &c.conf, algos)
+			if err != nil {
+				t.Fatalf("%s: new writer failed: %s", format, err)
+			}
+
+			for _, r := range results {
+				if err := w.WriteResult(r); err != nil {
+					t.Fatalf("%s: write failed: %s", format, err)
+				}
+			}
+
+			if err := w.Close(); err != nil {
+				t.Fatalf("%s: close failed: %s", format, err)
+			}
+
+			checkGolden(t, c.name+"."+format+".golden", out.Bytes())
+
+			// records are one table which csv readers can read whole
+			if format == "csv" || format == "tsv" {
+				reader := csv.NewReader(bytes.NewReader(out.Bytes()))
+				if format == "tsv" {
+					reader.Comma = '\t'
+				}
+
+				rows, err := reader.ReadAll()
+				if err != nil || len(rows[0]) != len(RECORD_FIELDS) {
+					t.Errorf("%s %s: read as one table failed: %v", c.name, format, err)
+				}
+			}
+		}
+	}
+
+	// tags are the registered names whatever alias or algorithms are given
+	for _, spec := range []string{"sha-256", "SHA256", "crc32", "crc32,md5"} {
+		algos, _ := ParseAlgorithms(spec)
+		out := bytes.NewBuffer(nil)
+		w, _ := NewResultWriter("plain", out, &CksumConfigure{Tag: true}, algos)
+		for _, r := range testResults(t, algos)[:1] {
+			_ = w.WriteResult(r)
+		}
+
+		exp := strings.ToUpper(algos[0].Name) + " (n.txt) = "
+		if !strings.HasPrefix(out.String(), exp) {
+			t.Errorf("%s: got %q; expected prefix %q", spec, out.String(), exp)
+		}
+	}
+
+	if _, err := NewResultWriter("xml", nil, &CksumConfigure{}, nil); err == nil {
+		t.Errorf("expected an error of unknown format")
+	}
+}
+
+func TestSummary(t *testing.T) {
+	algos, err := ParseAlgorithms("crc32,adler32")
+	if err != nil {
+		t.Fatalf("parse failed: %s", err)
+	}
+
+	conf := &CksumConfigure{ToCheck: true, Expect: "cbf43926"}
+	summary := NewSummary(algos)
+	for _, r := range testResults(t, algos) {
+		summary.Add(NewRecords(conf, algos, r))
+	}
+
+	exp := Summary{[]string{"CRC-32/ISO-HDLC", "adler32"}, 2, 9, 1, 1, 1}
+	if !reflect.DeepEqual(summary, exp) {
+		t.Errorf("got %+v, expected %+v", summary, exp)
+	}
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	IP_PROTOCOL_ICMP   = 1
+	IP_PROTOCOL_TCP    = 6
+	IP_PROTOCOL_UDP    = 17
+	IP_PROTOCOL_ICMPV6 = 58
+
+	IPV6_HEADER_LENGTH = 40
+)
+
+// IPV6_EXTENSION_HEADERS are the extension headers skipped to find the upper
+// layer protocol, fragment headers are not included since a fragment can not
+// be checked alone.
+var IPV6_EXTENSION_HEADERS = map[byte]bool{
+	0:  true, // hop-by-hop options
+	43: true, // routing
+	60: true, // destination options
+}
+
+// PacketChecksum is a checksum field of a packet. Offset is the offset of
+// the field in the packet.
+type PacketChecksum struct {
+	Name     string
+	Offset   int
+	Stored   uint16
+	Computed uint16
+}
+
+func (p PacketChecksum) OK() bool {
+	return p.Stored == p.Computed
+}
+
+func (p PacketChecksum) String() string {
+	if p.OK() {
+		return fmt.Sprintf("%s checksum 0x%04x ok", p.Name, p.Stored)
+	}
+
+	return fmt.Sprintf("%s checksum 0x%04x, expected 0x%04x, MISMATCH", p.Name, p.Stored, p.Computed)
+}
+
+// internetChecksumOf computes the checksum of parts, with the 16-bit field
+// at offset skip of the last part taken as zero.
+func internetChecksumOf(skip int, parts ...[]byte) uint16 {
+	c := NewInternetChecksum()
+	for i, part := range parts {
+		if i < len(parts)-1 {
+			_ = c.Update(part)
+			continue
+		}
+
+		_ = c.Update(part[:skip])
+		_ = c.Update([]byte{0, 0})
+		_ = c.Update(part[skip+2:])
+	}
+
+	return uint16(c.Checksum())
+}
+
+func ipv4PseudoHeader(header []byte, protocol byte, length int) []byte {
+	pseudo := make([]byte, 12)
+	copy(pseudo[0:8], header[12:20])
+	pseudo[9] = protocol
+	binary.BigEndian.PutUint16(pseudo[10:], uint16(length))
+	return pseudo
+}
+
+func ipv6PseudoHeader(header []byte, protocol byte, length int) []byte {
+	pseudo := make([]byte, 40)
+	copy(pseudo[0:32], header[8:40])
+	binary.BigEndian.PutUint32(pseudo[32:], uint32(length))
+	pseudo[39] = protocol
+	return pseudo
+}
+
+// checkTransport computes the checksum of the upper layer segment at offset
+// of packet, pseudo is nil for ICMP.
+func checkTransport(packet []byte, offset int, protocol byte, pseudo []byte) ([]PacketChecksum, error) {
+	segment := packet[offset:]
+	name := ""
+	field := 0
+	switch protocol {
+	case IP_PROTOCOL_TCP:
+		name, field = "tcp", 16
+	case IP_PROTOCOL_UDP:
+		name, field = "udp", 6
+	case IP_PROTOCOL_ICMP:
+		name, field = "icmp", 2
+	case IP_PROTOCOL_ICMPV6:
+		name, field = "icmpv6", 2
+	default:
+		return nil, nil
+	}
+
+	if len(segment) < field+2 {
+		return nil, fmt.Errorf("%s segment too short, %d bytes", name, len(segment))
+	}
+
+	stored := binary.BigEndian.Uint16(segment[field:])
+	var computed uint16
+	if pseudo == nil {
+		computed = internetChecksumOf(field, segment)
+	} else {
+		computed = internetChecksumOf(field, pseudo, segment)
+	}
+
+	if protocol == IP_PROTOCOL_UDP && computed == 0 {
+		computed = 0xffff
+	}
+
+	return []PacketChecksum{{name, offset + field, stored, computed}}, nil
+}
+
+func checkIPv4Packet(packet []byte) ([]PacketChecksum, error) {
+	if len(packet) < 20 {
+		return nil, fmt.Errorf("IPv4 packet too short, %d bytes", len(packet))
+	}
+
+	headerLength := int(packet[0]&0x0f) * 4
+	totalLength := int(binary.BigEndian.Uint16(packet[2:]))
+	if headerLength < 20 || totalLength < headerLength || totalLength > len(packet) {
+		return nil, errors.New("invalid IPv4 header or total length")
+	}
+
+	packet = packet[:totalLength]
+	header := packet[:headerLength]
+	results := []PacketChecksum{{
+		Name:     "ipv4",
+		Offset:   10,
+		Stored:   binary.BigEndian.Uint16(header[10:]),
+		Computed: internetChecksumOf(10, header),
+	}}
+
+	// only the first fragment has the upper layer header, and the checksum
+	// covers all fragments
+	flags := binary.BigEndian.Uint16(packet[6:])
+	if flags&0x3fff != 0 {
+		return results, nil
+	}
+
+	// checksum of UDP over IPv4 is optional, 0 means no checksum
+	protocol := packet[9]
+	if protocol == IP_PROTOCOL_UDP && len(packet) >= headerLength+8 &&
+		binary.BigEndian.Uint16(packet[headerLength+6:]) == 0 {
+		return results, nil
+	}
+
+	var pseudo []byte
+	if protocol != IP_PROTOCOL_ICMP {
+		pseudo = ipv4PseudoHeader(header, protocol, totalLength-headerLength)
+	}
+
+	more, err := checkTransport(packet, headerLength, protocol, pseudo)
+	return append(results, more...), err
+}
+
+func checkIPv6Packet(packet []byte) ([]PacketChecksum, error) {
+	if len(packet) < IPV6_HEADER_LENGTH {
+		return nil, fmt.Errorf("IPv6 packet too short, %d bytes", len(packet))
+	}
+
+	payloadLength := int(binary.BigEndian.Uint16(packet[4:]))
+	if IPV6_HEADER_LENGTH+payloadLength > len(packet) {
+		return nil, errors.New("invalid IPv6 payload length")
+	}
+
+	packet = packet[:IPV6_HEADER_LENGTH+payloadLength]
+	next := packet[6]
+	offset := IPV6_HEADER_LENGTH
+	for IPV6_EXTENSION_HEADERS[next] {
+		if offset+8 > len(packet) {
+			return nil, errors.New("truncated IPv6 extension header")
+		}
+
+		next = packet[offset]
+		offset += (int(packet[offset+1]) + 1) * 8
+	}
+
+	if offset > len(packet) {
+		return nil, errors.New("truncated IPv6 extension header")
+	}
+
+	// ICMP for IPv4 is not expected in IPv6 and has no pseudo-header
+	if next == IP_PROTOCOL_ICMP {
+		return nil, nil
+	}
+
+	pseudo := ipv6PseudoHeader(packet, next, len(packet)-offset)
+	return checkTransport(packet, offset, next, pseudo)
+}
+
+// CheckIPPacket computes the IPv4 header, TCP, UDP, ICMP and ICMPv6
+// checksums of a raw IPv4 or IPv6 packet, and returns them with the stored
+// values.
+func CheckIPPacket(packet []byte) ([]PacketChecksum, error) {
+	if len(packet) == 0 {
+		return nil, errors.New("empty packet")
+	}
+
+	switch packet[0] >> 4 {
+	case 4:
+		return checkIPv4Packet(packet)
+	case 6:
+		return checkIPv6Packet(packet)
+	default:
+		return nil, fmt.Errorf("unknown IP version %d", packet[0]>>4)
+	}
+}
+
+// FixIPPacket writes the computed checksums into packet. The pseudo-headers
+// do not include the IPv4 header checksum, so the checksums do not depend on
+// each other.
+func FixIPPacket(packet []byte) ([]PacketChecksum, error) {
+	results, err := CheckIPPacket(packet)
+	for _, r := range results {
+		binary.BigEndian.PutUint16(packet[r.Offset:], r.Computed)
+	}
+
+	return results, err
+}
+
+const (
+	PCAP_MAGIC_MICROSECONDS = 0xa1b2c3d4
+	PCAP_MAGIC_NANOSECONDS  = 0xa1b23c4d
+
+	LINKTYPE_ETHERNET  = 1
+	LINKTYPE_RAW       = 101
+	LINKTYPE_LINUX_SLL = 113
+	LINKTYPE_IPV4      = 228
+	LINKTYPE_IPV6      = 229
+
+	ETHERTYPE_IPV4 = 0x0800
+	ETHERTYPE_IPV6 = 0x86dd
+	ETHERTYPE_VLAN = 0x8100
+)
+
+// linkPayload returns the IP packet in a frame of linkType, or nil if the
+// frame does not carry IP.
+func linkPayload(linkType uint32, frame []byte) ([]byte, error) {
+	switch linkType {
+	case LINKTYPE_RAW, LINKTYPE_IPV4, LINKTYPE_IPV6:
+		return frame, nil
+
+	case LINKTYPE_ETHERNET:
+		offset := 12
+		for {
+			if len(frame) < offset+2 {
+				return nil, errors.New("truncated ethernet header")
+			}
+
+			etherType := binary.BigEndian.Uint16(frame[offset:])
+			offset += 2
+			switch etherType {
+			case ETHERTYPE_VLAN:
+				offset += 2
+			case ETHERTYPE_IPV4, ETHERTYPE_IPV6:
+				return frame[offset:], nil
+			default:
+				return nil, nil
+			}
+		}
+
+	case LINKTYPE_LINUX_SLL:
+		if len(frame) < 16 {
+			return nil, errors.New("truncated linux cooked header")
+		}
+
+		etherType := binary.BigEndian.Uint16(frame[14:])
+		if etherType != ETHERTYPE_IPV4 && etherType != ETHERTYPE_IPV6 {
+			return nil, nil
+		}
+		return frame[16:], nil
+
+	default:
+		return nil, fmt.Errorf("unsupported link type %d", linkType)
+	}
+}
+
+// ReadPcap calls fn with every IP packet in a pcap file, index starts at 1.
+// Truncated captures and frames without IP are passed as nil with an error
+// or nil.
+func ReadPcap(in io.Reader, fn func(index int, packet []byte, err error) error) error {
+	header := make([]byte, 24)
+	if _, err := io.ReadFull(in, header); err != nil {
+		return fmt.Errorf("read pcap header: %w", err)
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	magic := order.Uint32(header)
+	if magic != PCAP_MAGIC_MICROSECONDS && magic != PCAP_MAGIC_NANOSECONDS {
+		order = binary.BigEndian
+		magic = order.Uint32(header)
+		if magic != PCAP_MAGIC_MICROSECONDS && magic != PCAP_MAGIC_NANOSECONDS {
+			return errors.New("not a pcap file")
+		}
+	}
+
+	linkType := order.Uint32(header[20:]) & 0x0fffffff
+	record := make([]byte, 16)
+	for index := 1; ; index++ {
+		if _, err := io.ReadFull(in, record); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("packet %d: %w", index, err)
+		}
+
+		captured := order.Uint32(record[8:])
+		original := order.Uint32(record[12:])
+		if captured > 0x40000 {
+			return fmt.Errorf("packet %d: invalid captured length %d", index, captured)
+		}
+
+		frame := make([]byte, captured)
+		if _, err := io.ReadFull(in, frame); err != nil {
+			return fmt.Errorf("packet %d: %w", index, err)
+		}
+
+		var packet []byte
+		var err error
+		if captured < original {
+			err = fmt.Errorf("truncated capture, %d of %d bytes", captured, original)
+		} else {
+			packet, err = linkPayload(linkType, frame)
+		}
+
+		if err := fn(index, packet, err); err != nil {
+			return err
+		}
+	}
+}
+
+// checkPacketFile checks a raw packet, or every packet of a pcap file if
+// pcap is true, and writes a line for each packet. It returns false if any
+// checksum mismatches or any packet can not be parsed.
+func checkPacketFile(in io.Reader, out io.Writer, name string, pcap bool) (bool, error) {
+	ok := true
+	report := func(index int, packet []byte, err error) error {
+		label := name
+		if pcap {
+			label = fmt.Sprintf("%s#%d", name, index)
+		}
+
+		var results []PacketChecksum
+		if err == nil && packet != nil {
+			results, err = CheckIPPacket(packet)
+		}
+
+		line := ""
+		for _, r := range results {
+			ok = ok && r.OK()
+			line += ", " + r.String()
+		}
+
+		switch {
+		case err != nil:
+			ok = false
+			line += ", error: " + err.Error()
+		case packet == nil:
+			line += ", not an IP packet"
+		case len(results) == 0:
+			line += ", no checksum"
+		}
+
+		_, errWrite := fmt.Fprintf(out, "%s: %s\n", label, line[2:])
+		return errWrite
+	}
+
+	if pcap {
+		err := ReadPcap(in, report)
+		return ok, err
+	}
+
+	packet, err := io.ReadAll(in)
+	if err != nil {
+		return false, err
+	}
+
+	return ok, report(1, packet, nil)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// packets built and checksummed by an independent implementation
+var TEST_PACKETS = map[string]string{
+	"tcp4":  "4500002d123440004006a67ec0a80001c0a800c79c40005000000001000000005002ffff4d61000068656c6c6f",
+	"udp4":  "45000020000100004011f8b3c0a80001c0a800c704d20035000c90dc74657374",
+	"udp6":  "60000000000b114020010db800000000000000000000000120010db800000000000000000000000214e90035000bcae2616263",
+	"icmp6": "60000000000c3a4020010db800000000000000000000000120010db80000000000000000000000028000456b0007000170696e67",
+}
+
+func TestCheckIPPacket(t *testing.T) {
+	expected := map[string][]string{
+		"tcp4":  {"ipv4", "tcp"},
+		"udp4":  {"ipv4", "udp"},
+		"udp6":  {"udp"},
+		"icmp6": {"icmpv6"},
+	}
+
+	for name, s := range TEST_PACKETS {
+		packet, _ := hex.DecodeString(s)
+		results, err := CheckIPPacket(packet)
+		if err != nil {
+			t.Fatalf("%s: check failed: %s", name, err)
+		}
+
+		if len(results) != len(expected[name]) {
+			t.Fatalf("%s: got %d checksums; expected %d", name, len(results), len(expected[name]))
+		}
+
+		for i, r := range results {
+			if r.Name != expected[name][i] || !r.OK() {
+				t.Errorf("%s: got %s", name, r)
+			}
+		}
+
+		// a changed payload byte is detected, and fixed
+		packet[len(packet)-1] ^= 0x5a
+		results, _ = CheckIPPacket(packet)
+		if last := results[len(results)-1]; last.OK() {
+			t.Errorf("%s: mismatch not detected", name)
+		}
+
+		_, _ = FixIPPacket(packet)
+		results, _ = CheckIPPacket(packet)
+		for _, r := range results {
+			if !r.OK() {
+				t.Errorf("%s: after fix got %s", name, r)
+			}
+		}
+	}
+}
+
+func TestCheckICMPPacket(t *testing.T) {
+	// an echo request of ping in Windows, which ICMP checksum is 0x4d5a
+	icmp, _ := hex.DecodeString("08004d5a00010001" + hex.EncodeToString([]byte("abcdefghijklmnopqrstuvwabcdefghi")))
+	header, _ := hex.DecodeString("4500003c000100008001" + "0000" + "c0a80001c0a800c7")
+	packet := append(header, icmp...)
+
+	results, err := FixIPPacket(packet)
+	if err != nil {
+		t.Fatalf("check failed: %s", err)
+	}
+
+	if len(results) != 2 || results[1].Name != "icmp" || !results[1].OK() || results[1].Computed != 0x4d5a {
+		t.Errorf("got %v", results)
+	}
+}
+
+func TestCheckPcapFile(t *testing.T) {
+	pcap := bytes.NewBuffer(nil)
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header[0:], PCAP_MAGIC_MICROSECONDS)
+	binary.LittleEndian.PutUint16(header[4:], 2)
+	binary.LittleEndian.PutUint16(header[6:], 4)
+	binary.LittleEndian.PutUint32(header[16:], 65535)
+	binary.LittleEndian.PutUint32(header[20:], LINKTYPE_ETHERNET)
+	pcap.Write(header)
+
+	addFrame := func(etherType uint16, payload []byte) {
+		frame := make([]byte, 14, 14+len(payload))
+		binary.BigEndian.PutUint16(frame[12:], etherType)
+		frame = append(frame, payload...)
+
+		record := make([]byte, 16)
+		binary.LittleEndian.PutUint32(record[8:], uint32(len(frame)))
+		binary.LittleEndian.PutUint32(record[12:], uint32(len(frame)))
+		pcap.Write(record)
+		pcap.Write(frame)
+	}
+
+	tcp4, _ := hex.DecodeString(TEST_PACKETS["tcp4"])
+	udp6, _ := hex.DecodeString(TEST_PACKETS["udp6"])
+	addFrame(ETHERTYPE_IPV4, tcp4)
+	udp6[len(udp6)-1] ^= 1
+	addFrame(ETHERTYPE_IPV6, udp6)
+	addFrame(0x0806, []byte{0, 1, 8, 0})
+
+	out := bytes.NewBuffer(nil)
+	ok, err := checkPacketFile(pcap, out, "test.pcap", true)
+	if err != nil {
+		t.Fatalf("check failed: %s", err)
+	}
+
+	if ok {
+		t.Errorf("expected a mismatch")
+	}
+
+	exp := strings.Join([]string{
+		"test.pcap#1: ipv4 checksum 0xa67e ok, tcp checksum 0x4d61 ok",
+		"test.pcap#2: udp checksum 0xcae2, expected 0xcbe2, MISMATCH",
+		"test.pcap#3: not an IP packet",
+		"",
+	}, "\n")
+	if out.String() != exp {
+		t.Errorf("got\n%s\nexpected\n%s", out.String(), exp)
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const MAX_SUGGESTIONS = 3
+
+// Algorithm is a registered checksum algorithm. Size is the output size in
+// bytes.
+type Algorithm struct {
+	Name        string
+	Aliases     []string
+	Description string
+	Size        int
+	New         func() Checksum
+}
+
+// ALGORITHMS maps lower case names and aliases to registered algorithms.
+var ALGORITHMS = make(map[string]*Algorithm)
+
+// Register adds algo to ALGORITHMS, a name or alias can not be registered
+// twice.
+func Register(algo Algorithm) error {
+	names := append([]string{algo.Name}, algo.Aliases...)
+	for _, name := range names {
+		if _, found := ALGORITHMS[strings.ToLower(name)]; found {
+			return fmt.Errorf("algorithm '%s' is already registered", name)
+		}
+	}
+
+	a := &algo
+	for _, name := range names {
+		ALGORITHMS[strings.ToLower(name)] = a
+	}
+
+	return nil
+}
+
+func mustRegister(algo Algorithm) {
+	if err := Register(algo); err != nil {
+		panic(err)
+	}
+}
+
+// Algorithms returns every registered algorithm sorted by name.
+func Algorithms() []*Algorithm {
+	seen := make(map[*Algorithm]bool)
+	list := make([]*Algorithm, 0, len(ALGORITHMS))
+	for _, a := range ALGORITHMS {
+		if !seen[a] {
+			seen[a] = true
+			list = append(list, a)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name)
+	})
+
+	return list
+}
+
+// editDistance is the optimal string alignment distance of a and b, where
+// swapping two adjacent characters counts as one edit.
+func editDistance(a string, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = d[i-1][j-1] + cost
+			if d[i-1][j]+1 < d[i][j] {
+				d[i][j] = d[i-1][j] + 1
+			}
+			if d[i][j-1]+1 < d[i][j] {
+				d[i][j] = d[i][j-1] + 1
+			}
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
+		}
+	}
+
+	return d[len(a)][len(b)]
+}
+
+// SuggestAlgorithms returns up to MAX_SUGGESTIONS registered names close to
+// name, by edit distance or containing name. Each algorithm is suggested
+// once, by its closest name or alias.
+func SuggestAlgorithms(name string) []string {
+	name = strings.ToLower(name)
+	limit := len(name)/3 + 1
+
+	type candidate struct {
+		name     string
+		distance int
+	}
+
+	best := make(map[*Algorithm]candidate)
+	for key, a := range ALGORITHMS {
+		d := editDistance(name, key)
+		if d > limit && !strings.Contains(key, name) {
+			continue
+		}
+
+		if c, found := best[a]; !found || d < c.distance || (d == c.distance && key < c.name) {
+			best[a] = candidate{key, d}
+		}
+	}
+
+	candidates := make([]candidate, 0, len(best))
+	for _, c := range best {
+		candidates = append(candidates, c)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	suggestions := make([]string, 0, MAX_SUGGESTIONS)
+	for i := 0; i < len(candidates) && i < MAX_SUGGESTIONS; i++ {
+		suggestions = append(suggestions, candidates[i].name)
+	}
+
+	return suggestions
+}
+
+// LookupAlgorithm finds a registered algorithm by name or alias, case
+// insensitive. The error of an unknown name suggests similar names.
+func LookupAlgorithm(name string) (*Algorithm, error) {
+	if a, found := ALGORITHMS[strings.ToLower(name)]; found {
+		return a, nil
+	}
+
+	suggestions := SuggestAlgorithms(name)
+	if len(suggestions) == 0 {
+		return nil, fmt.Errorf("unknown algorithm '%s', use -list to show all algorithms", name)
+	}
+
+	return nil, fmt.Errorf("unknown algorithm '%s', did you mean %s?", name, strings.Join(suggestions, ", "))
+}
+
+// ParseAlgorithms looks up a comma separated list of algorithms.
+func ParseAlgorithms(spec string) ([]*Algorithm, error) {
+	algos := make([]*Algorithm, 0)
+	for _, name := range strings.Split(spec, ",") {
+		a, err := LookupAlgorithm(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+
+		algos = append(algos, a)
+	}
+
+	return algos, nil
+}
+
+// NewChecksum returns a new checksum of a registered algorithm, or nil if
+// algo is unknown.
+func NewChecksum(algo string) Checksum {
+	a, err := LookupAlgorithm(algo)
+	if err != nil {
+		return nil
+	}
+
+	return a.New()
+}
+
+// ListAlgorithms writes name, size in bytes, description and aliases of
+// every registered algorithm.
+func ListAlgorithms(out io.Writer) error {
+	for _, a := range Algorithms() {
+		aliases := ""
+		if len(a.Aliases) > 0 {
+			aliases = " (" + strings.Join(a.Aliases, ", ") + ")"
+		}
+
+		_, err := fmt.Fprintf(out, "%-18s %3d  %s%s\n", a.Name, a.Size, a.Description, aliases)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func init() {
+	mustRegister(Algorithm{"net", []string{"internet", "rfc1071"}, "Internet checksum of RFC 1071", 2,
+		func() Checksum { return NewInternetChecksum() }})
+	mustRegister(Algorithm{"bsd", []string{"sum-r"}, "BSD sum, as sum -r", 2,
+		func() Checksum { return NewBSDChecksum() }})
+	mustRegister(Algorithm{"sysv", []string{"sum-s"}, "System V sum, as sum -s", 2,
+		func() Checksum { return NewSysVChecksum() }})
+	mustRegister(Algorithm{"posix", []string{"cksum"}, "POSIX cksum, CRC-32 with length", 4,
+		func() Checksum { return NewPosixChecksum() }})
+	mustRegister(Algorithm{"adler32", []string{"adler-32"}, "Adler-32 of zlib, RFC 1950", 4,
+		func() Checksum { return NewAdler32Checksum() }})
+	mustRegister(Algorithm{"fletcher16", []string{"fletcher-16"}, "Fletcher checksum of 8-bit words", 2,
+		func() Checksum { return asHash32(NewFletcher16Checksum()) }})
+	mustRegister(Algorithm{"fletcher32", []string{"fletcher-32"}, "Fletcher checksum of 16-bit words", 4,
+		func() Checksum { return asHash32(NewFletcher32Checksum()) }})
+	mustRegister(Algorithm{"fletcher64", []string{"fletcher-64"}, "Fletcher checksum of 32-bit words", 8,
+		func() Checksum { return NewFletcher64Checksum() }})
+	mustRegister(Algorithm{"rsync", nil, "rsync weak rolling checksum", 4,
+		func() Checksum { return NewRsyncChecksum() }})
+	mustRegister(Algorithm{"buzhash", nil, "Buzhash cyclic polynomial rolling hash", 4,
+		func() Checksum { return NewBuzhash() }})
+
+	for _, model := range CRC_MODELS {
+		m := model
+		mustRegister(Algorithm{
+			Name:    m.Name,
+			Aliases: m.Aliases,
+			Description: fmt.Sprintf("CRC width %d, poly 0x%x, init 0x%x, refin %v, refout %v, xorout 0x%x",
+				m.Width, m.Poly, m.Init, m.RefIn, m.RefOut, m.XorOut),
+			Size: (m.Width + 7) / 8,
+			New:  func() Checksum { return asHash32(NewCRC(m)) },
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	for _, a := range Algorithms() {
+		c := a.New()
+		if c.Size() != a.Size {
+			t.Errorf("%s: got size %d; registered %d", a.Name, c.Size(), a.Size)
+		}
+
+		for _, alias := range a.Aliases {
+			if found, err := LookupAlgorithm(alias); err != nil || found != a {
+				t.Errorf("%s: alias %s not found", a.Name, alias)
+			}
+		}
+	}
+
+	if err := Register(Algorithm{Name: "SHA256"}); err == nil {
+		t.Errorf("expected an error of registering a name twice")
+	}
+
+	if NewChecksum("no-such-algorithm") != nil {
+		t.Errorf("expected nil for an unknown algorithm")
+	}
+}
+
+func TestLookupAlgorithmSuggestions(t *testing.T) {
+	cases := []struct {
+		name string
+		exp  []string
+	}{
+		{"sha-265", []string{"sha-256", "sha-224", "sha3-256"}},
+		{"fletcher", []string{"fletcher16", "fletcher32", "fletcher64"}},
+		{"qwertyuiop", []string{}},
+	}
+
+	for _, c := range cases {
+		got := SuggestAlgorithms(c.name)
+		if !reflect.DeepEqual(got, c.exp) {
+			t.Errorf("suggestions of %s: got %v; expected %v", c.name, got, c.exp)
+		}
+
+		_, err := LookupAlgorithm(c.name)
+		if err == nil || !strings.Contains(err.Error(), c.name) {
+			t.Errorf("lookup %s: got error %v", c.name, err)
+		}
+	}
+}
+
+func TestListAlgorithms(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := ListAlgorithms(out); err != nil {
+		t.Fatalf("list failed: %s", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != len(Algorithms()) {
+		t.Errorf("got %d lines; expected %d", len(lines), len(Algorithms()))
+	}
+
+	exp := "sha256              32  SHA-256 of FIPS 180-4 (sha-256)"
+	if !strings.Contains(out.String(), exp+"\n") {
+		t.Errorf("line %q not found in\n%s", exp, out.String())
+	}
+}
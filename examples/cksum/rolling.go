@@ -0,0 +1,162 @@
+package main
+
+// RollingChecksum is a checksum of a window of bytes, the data written since
+// reset, which can slide by one byte in constant time. Roll removes out, the
+// first byte of the window, and appends in.
+type RollingChecksum interface {
+	Checksum
+	Roll(out byte, in byte)
+}
+
+// RsyncChecksum is the weak checksum of rsync, a pair of 16-bit sums like
+// Adler-32 without the modulo prime.
+type RsyncChecksum struct {
+	a      uint16
+	b      uint16
+	length uint16
+}
+
+func NewRsyncChecksum() *RsyncChecksum {
+	c := &RsyncChecksum{}
+	c.Reset()
+
+	return c
+}
+
+func (c *RsyncChecksum) Reset() {
+	c.a = 0
+	c.b = 0
+	c.length = 0
+}
+
+func (c *RsyncChecksum) BlockSize() int {
+	return 1024
+}
+
+func (c *RsyncChecksum) Size() int {
+	return 4
+}
+
+func (c *RsyncChecksum) Update(data []byte) error {
+	a, b := c.a, c.b
+	for _, x := range data {
+		a += uint16(x)
+		b += a
+	}
+
+	c.a, c.b = a, b
+	c.length += uint16(len(data))
+	return nil
+}
+
+func (c *RsyncChecksum) Roll(out byte, in byte) {
+	c.a += uint16(in) - uint16(out)
+	c.b += c.a - c.length*uint16(out)
+}
+
+func (c *RsyncChecksum) Checksum() uint64 {
+	return uint64(c.b)<<16 | uint64(c.a)
+}
+
+func (c *RsyncChecksum) Sum(b []byte) []byte {
+	return appendChecksum(b, c.Checksum(), c.Size())
+}
+
+func (c *RsyncChecksum) Write(data []byte) (int, error) {
+	return len(data), c.Update(data)
+}
+
+func (c *RsyncChecksum) Sum32() uint32 {
+	return uint32(c.Checksum())
+}
+
+func (c *RsyncChecksum) Sum64() uint64 {
+	return c.Checksum()
+}
+
+// BUZHASH_TABLE maps bytes to random 32-bit values, generated by splitmix64
+// from a fixed seed so checksums are stable.
+var BUZHASH_TABLE = buzhashTable(0x6275_7a68_6173_6821)
+
+func buzhashTable(seed uint64) [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = uint32(z ^ (z >> 31))
+	}
+
+	return table
+}
+
+func rotl32(x uint32, n uint) uint32 {
+	n %= 32
+	return x<<n | x>>(32-n)
+}
+
+// Buzhash is the cyclic polynomial rolling hash, a Rabin-Karp hash where
+// multiplication is replaced by rotation, so bytes are not only summed and
+// reordered windows have different checksums.
+type Buzhash struct {
+	hash   uint32
+	length uint
+}
+
+func NewBuzhash() *Buzhash {
+	c := &Buzhash{}
+	c.Reset()
+
+	return c
+}
+
+func (c *Buzhash) Reset() {
+	c.hash = 0
+	c.length = 0
+}
+
+func (c *Buzhash) BlockSize() int {
+	return 1024
+}
+
+func (c *Buzhash) Size() int {
+	return 4
+}
+
+func (c *Buzhash) Update(data []byte) error {
+	h := c.hash
+	for _, x := range data {
+		h = rotl32(h, 1) ^ BUZHASH_TABLE[x]
+	}
+
+	c.hash = h
+	c.length = (c.length + uint(len(data))) % 32
+	return nil
+}
+
+// Roll rotates the hash once more, so out has been rotated by the length of
+// the window and is removed with the same rotation.
+func (c *Buzhash) Roll(out byte, in byte) {
+	c.hash = rotl32(c.hash, 1) ^ rotl32(BUZHASH_TABLE[out], c.length) ^ BUZHASH_TABLE[in]
+}
+
+func (c *Buzhash) Checksum() uint64 {
+	return uint64(c.hash)
+}
+
+func (c *Buzhash) Sum(b []byte) []byte {
+	return appendChecksum(b, c.Checksum(), c.Size())
+}
+
+func (c *Buzhash) Write(data []byte) (int, error) {
+	return len(data), c.Update(data)
+}
+
+func (c *Buzhash) Sum32() uint32 {
+	return uint32(c.Checksum())
+}
+
+func (c *Buzhash) Sum64() uint64 {
+	return c.Checksum()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"hash/adler32"
+	"math/rand"
+	"testing"
+)
+
+func TestRollingChecksums(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	data := make([]byte, 3000)
+	r.Read(data)
+
+	for _, algo := range []string{"rsync", "buzhash", "adler32"} {
+		for _, size := range []int{1, 16, 31, 32, 33, 700} {
+			rolling := NewChecksum(algo).(RollingChecksum)
+			_ = rolling.Update(data[:size])
+
+			fresh := NewChecksum(algo)
+			for i := size; i < len(data); i++ {
+				rolling.Roll(data[i-size], data[i])
+
+				fresh.Reset()
+				_ = fresh.Update(data[i-size+1 : i+1])
+				if rolling.Checksum() != fresh.Checksum() {
+					t.Fatalf("%s window %d at %d: got 0x%x; expected 0x%x",
+						algo, size, i, rolling.Checksum(), fresh.Checksum())
+				}
+
+				if algo == "adler32" && rolling.Checksum() != uint64(adler32.Checksum(data[i-size+1:i+1])) {
+					t.Fatalf("adler32 window %d at %d: does not match hash/adler32", size, i)
+				}
+			}
+		}
+	}
+}
+
+func TestRsyncChecksum(t *testing.T) {
+	// s1 is the sum of bytes, and s2 the sum of s1 after each byte
+	c := NewRsyncChecksum()
+	_ = c.Update([]byte("abc"))
+	a := uint64('a' + 'b' + 'c')
+	b := uint64(3*'a' + 2*'b' + 'c')
+	if got := c.Checksum(); got != b<<16|a {
+		t.Errorf("got 0x%x; expected 0x%x", got, b<<16|a)
+	}
+}
+
+func TestBuzhashOrder(t *testing.T) {
+	c := NewBuzhash()
+	_ = c.Update([]byte("ab"))
+	ab := c.Checksum()
+
+	c.Reset()
+	_ = c.Update([]byte("ba"))
+	if c.Checksum() == ab {
+		t.Errorf("buzhash of reordered bytes should differ")
+	}
+}
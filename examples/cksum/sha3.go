@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+var KECCAK_ROUND_CONSTANTS = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+// KECCAK_ROTATIONS and KECCAK_PI_LANES are the rho offsets and pi lane order,
+// walked from lane 1.
+var KECCAK_ROTATIONS = [24]int{
+	1, 3, 6, 10, 15, 21, 28, 36, 45, 55, 2, 14, 27, 41, 56, 8, 25, 43, 62, 18, 39, 61, 20, 44,
+}
+
+var KECCAK_PI_LANES = [24]int{
+	10, 7, 11, 17, 18, 3, 5, 16, 8, 21, 24, 4, 15, 23, 19, 13, 12, 2, 20, 14, 22, 9, 6, 1,
+}
+
+func keccakF1600(a *[25]uint64) {
+	var c [5]uint64
+	for round := 0; round < 24; round++ {
+		// theta
+		for i := 0; i < 5; i++ {
+			c[i] = a[i] ^ a[i+5] ^ a[i+10] ^ a[i+15] ^ a[i+20]
+		}
+
+		for i := 0; i < 5; i++ {
+			t := c[(i+4)%5] ^ bits.RotateLeft64(c[(i+1)%5], 1)
+			for j := 0; j < 25; j += 5 {
+				a[j+i] ^= t
+			}
+		}
+
+		// rho and pi
+		t := a[1]
+		for i := 0; i < 24; i++ {
+			j := KECCAK_PI_LANES[i]
+			t, a[j] = a[j], bits.RotateLeft64(t, KECCAK_ROTATIONS[i])
+		}
+
+		// chi
+		for j := 0; j < 25; j += 5 {
+			copy(c[:], a[j:j+5])
+			for i := 0; i < 5; i++ {
+				a[j+i] ^= (^c[(i+1)%5]) & c[(i+2)%5]
+			}
+		}
+
+		// iota
+		a[0] ^= KECCAK_ROUND_CONSTANTS[round]
+	}
+}
+
+// SHA3 is the SHA-3 hash of FIPS 202 with a digest of size bytes.
+type SHA3 struct {
+	state [25]uint64
+	size  int
+	rate  int
+	buf   []byte
+}
+
+// NewSHA3 returns a SHA3 hash with a digest of size bytes, which is 28, 32,
+// 48 or 64.
+func NewSHA3(size int) hash.Hash {
+	h := &SHA3{
+		size: size,
+		rate: 200 - 2*size,
+	}
+	h.buf = make([]byte, 0, h.rate)
+
+	return h
+}
+
+func (h *SHA3) Reset() {
+	h.state = [25]uint64{}
+	h.buf = h.buf[:0]
+}
+
+func (h *SHA3) Size() int {
+	return h.size
+}
+
+func (h *SHA3) BlockSize() int {
+	return h.rate
+}
+
+func (h *SHA3) absorb(block []byte) {
+	for i := 0; i < len(block)/8; i++ {
+		h.state[i] ^= binary.LittleEndian.Uint64(block[8*i:])
+	}
+
+	keccakF1600(&h.state)
+}
+
+func (h *SHA3) Write(data []byte) (int, error) {
+	n := len(data)
+	if len(h.buf) > 0 {
+		k := copy(h.buf[len(h.buf):h.rate], data)
+		h.buf = h.buf[:len(h.buf)+k]
+		data = data[k:]
+		if len(h.buf) < h.rate {
+			return n, nil
+		}
+
+		h.absorb(h.buf)
+		h.buf = h.buf[:0]
+	}
+
+	for len(data) >= h.rate {
+		h.absorb(data[:h.rate])
+		data = data[h.rate:]
+	}
+
+	h.buf = append(h.buf, data...)
+	return n, nil
+}
+
+func (h *SHA3) Sum(b []byte) []byte {
+	d := *h
+	block := make([]byte, d.rate)
+	copy(block, d.buf)
+	block[len(d.buf)] ^= 0x06
+	block[d.rate-1] ^= 0x80
+	d.absorb(block)
+
+	out := make([]byte, d.size+8)
+	for i := 0; i < (d.size+7)/8; i++ {
+		binary.LittleEndian.PutUint64(out[8*i:], d.state[i])
+	}
+
+	return append(b, out[:d.size]...)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// GlobList is a flag of glob patterns, which can be given multiple times or
+// separated by commas.
+type GlobList []string
+
+func (g *GlobList) String() string {
+	return strings.Join(*g, ",")
+}
+
+func (g *GlobList) Set(value string) error {
+	for _, pattern := range strings.Split(value, ",") {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid glob '%s': %w", pattern, err)
+		}
+
+		*g = append(*g, pattern)
+	}
+
+	return nil
+}
+
+// Match returns true if any pattern matches the base name or the slash
+// separated relative path of a file.
+func (g GlobList) Match(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	base := filepath.Base(rel)
+	for _, pattern := range g {
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WalkFiles returns the regular files under root in lexical order. A file
+// is selected if it matches include, or include is empty, and does not match
+// exclude. Directories matching exclude are skipped entirely.
+func WalkFiles(root string, include GlobList, exclude GlobList) ([]string, error) {
+	files := make([]string, 0)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if rel != "." && exclude.Match(rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() || exclude.Match(rel) {
+			return nil
+		}
+
+		if len(include) == 0 || include.Match(rel) {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
+
+// FileResult is the checksums of a file, or the error reading it.
+type FileResult struct {
+	Name      string
+	Length    int
+	Checksums []Checksum
+	Err       error
+}
+
+// ChecksumFiles checksums names in up to workers goroutines, 0 means the
+// number of CPUs, and calls emit with the results in the order of names.
+// newChecksums is called for each file, so every result keeps its own
+// checksums.
+func ChecksumFiles(names []string, newChecksums func() []Checksum, workers int, emit func(FileResult)) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	results := make([]chan FileResult, len(names))
+	for i := range results {
+		results[i] = make(chan FileResult, 1)
+	}
+
+	jobs := make(chan int, len(names))
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(names); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				checksums := newChecksums()
+				length, err := checksumFile(names[i], checksums...)
+				results[i] <- FileResult{names[i], length, checksums, err}
+			}
+		}()
+	}
+
+	for i := range results {
+		emit(<-results[i])
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func makeTree(t *testing.T, files ...string) string {
+	dir := t.TempDir()
+	for _, name := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("mkdir failed: %s", err)
+		}
+
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("write failed: %s", err)
+		}
+	}
+
+	return dir
+}
+
+func TestWalkFiles(t *testing.T) {
+	dir := makeTree(t, "b.go", "a.txt", "sub/c.go", "sub/d_test.go", "vendor/e.go", "z/y/x.go")
+	cases := []struct {
+		include GlobList
+		exclude GlobList
+		exp     []string
+	}{
+		{nil, nil, []string{"a.txt", "b.go", "sub/c.go", "sub/d_test.go", "vendor/e.go", "z/y/x.go"}},
+		{GlobList{"*.go"}, GlobList{"*_test.go", "vendor"}, []string{"b.go", "sub/c.go", "z/y/x.go"}},
+		{GlobList{"sub/*"}, nil, []string{"sub/c.go", "sub/d_test.go"}},
+		{nil, GlobList{"z/y"}, []string{"a.txt", "b.go", "sub/c.go", "sub/d_test.go", "vendor/e.go"}},
+	}
+
+	for i, c := range cases {
+		files, err := WalkFiles(dir, c.include, c.exclude)
+		if err != nil {
+			t.Fatalf("case %d: walk failed: %s", i, err)
+		}
+
+		got := make([]string, len(files))
+		for j, f := range files {
+			rel, _ := filepath.Rel(dir, f)
+			got[j] = filepath.ToSlash(rel)
+		}
+
+		if !reflect.DeepEqual(got, c.exp) {
+			t.Errorf("case %d: got %v; expected %v", i, got, c.exp)
+		}
+	}
+}
+
+func TestChecksumFilesOrder(t *testing.T) {
+	names := make([]string, 0)
+	for i := 0; i < 50; i++ {
+		names = append(names, fmt.Sprintf("f%02d", i))
+	}
+
+	dir := makeTree(t, names...)
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(dir, name)
+	}
+	paths = append(paths, filepath.Join(dir, "missing"))
+
+	newChecksums := func() []Checksum { return []Checksum{NewChecksum("sha256")} }
+	for _, workers := range []int{1, 4, 0} {
+		i := 0
+		ChecksumFiles(paths, newChecksums, workers, func(r FileResult) {
+			if r.Name != paths[i] {
+				t.Errorf("workers %d: result %d is %s; expected %s", workers, i, r.Name, paths[i])
+			}
+
+			if i < len(names) {
+				c := NewChecksum("sha256")
+				_ = c.Update([]byte(names[i]))
+				if r.Err != nil || r.Length != 3 || !reflect.DeepEqual(r.Checksums[0].Sum(nil), c.Sum(nil)) {
+					t.Errorf("workers %d: wrong result of %s", workers, r.Name)
+				}
+
+			} else if r.Err == nil {
+				t.Errorf("workers %d: expected an error of missing file", workers)
+			}
+
+			i++
+		})
+
+		if i != len(paths) {
+			t.Errorf("workers %d: got %d results; expected %d", workers, i, len(paths))
+		}
+	}
+}
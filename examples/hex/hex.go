@@ -0,0 +1,515 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	DUMP_DEFAULT_WIDTH = 16
+	DUMP_DEFAULT_GROUP = 2
+	C_DEFAULT_WIDTH    = 12
+)
+
+var HEX_ENCODE_LOWER_MAP = []byte("0123456789abcdef")
+
+var HEX_ENCODE_UPPER_MAP = []byte("0123456789ABCDEF")
+
+var HEX_DECODE_MAP = makeHexDecodeMap()
+
+// makeHexDecodeMap builds a lookup table of hex digits in both cases,
+// other characters are marked as 0xff.
+func makeHexDecodeMap() []byte {
+	m := make([]byte, 256)
+	for i := range m {
+		m[i] = 0xff
+	}
+
+	for i := 0; i < 16; i++ {
+		m[HEX_ENCODE_LOWER_MAP[i]] = byte(i)
+		m[HEX_ENCODE_UPPER_MAP[i]] = byte(i)
+	}
+
+	return m
+}
+
+func isSpace(b byte) bool {
+	return b == '\n' || b == '\r' || b == ' ' || b == '\t'
+}
+
+func isPrintable(b byte) bool {
+	return b >= 0x20 && b < 0x7f
+}
+
+// readChunk fills buf from in, a short read only happens at the end of input.
+func readChunk(in *bufio.Reader, buf []byte) (int, error) {
+	n, err := io.ReadFull(in, buf)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = nil
+	}
+
+	return n, err
+}
+
+// HexEncodeFile writes in as plain hex digits, width bytes per line, 0 means
+// no line break.
+func HexEncodeFile(in io.Reader, out io.Writer, charmap []byte, width int) error {
+	reader := bufio.NewReader(in)
+	size := width
+	if size <= 0 {
+		size = 4096
+	}
+
+	buf := make([]byte, size)
+	line := make([]byte, 0, 2*size+1)
+	for {
+		n, err := readChunk(reader, buf)
+		if n == 0 {
+			if err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+			break
+		}
+
+		line = line[:0]
+		for _, b := range buf[:n] {
+			line = append(line, charmap[b>>4], charmap[b&0x0f])
+		}
+
+		if width > 0 {
+			line = append(line, '\n')
+		}
+
+		if _, err := out.Write(line); err != nil {
+			return err
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	if width <= 0 {
+		_, err := out.Write([]byte("\n"))
+		return err
+	}
+
+	return nil
+}
+
+// HexDumpFile writes in as xxd-style lines of offset, hex digits in groups of
+// group bytes and printable ASCII.
+func HexDumpFile(in io.Reader, out io.Writer, charmap []byte, width int, group int) error {
+	if width <= 0 {
+		width = DUMP_DEFAULT_WIDTH
+	}
+
+	if group <= 0 {
+		group = width
+	}
+
+	reader := bufio.NewReader(in)
+	buf := make([]byte, width)
+	hexWidth := 2*width + (width+group-1)/group - 1
+	line := make([]byte, 0, 10+hexWidth+2+width+1)
+
+	offset := 0
+	for {
+		n, err := readChunk(reader, buf)
+		if n == 0 {
+			if err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+			return nil
+		}
+
+		line = append(line[:0], fmt.Sprintf("%08x: ", offset)...)
+		start := len(line)
+		for i, b := range buf[:n] {
+			if i > 0 && i%group == 0 {
+				line = append(line, ' ')
+			}
+			line = append(line, charmap[b>>4], charmap[b&0x0f])
+		}
+
+		for len(line)-start < hexWidth+2 {
+			line = append(line, ' ')
+		}
+
+		for _, b := range buf[:n] {
+			if isPrintable(b) {
+				line = append(line, b)
+			} else {
+				line = append(line, '.')
+			}
+		}
+
+		line = append(line, '\n')
+		if _, err := out.Write(line); err != nil {
+			return err
+		}
+
+		offset += n
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+// CArrayName converts filename into a C identifier in the way of xxd -i.
+func CArrayName(filename string) string {
+	if filename == "-" || filename == "" {
+		return "data"
+	}
+
+	name := []byte(filename)
+	for i, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			name[i] = '_'
+		}
+	}
+
+	if name[0] >= '0' && name[0] <= '9' {
+		return "__" + string(name)
+	}
+
+	return string(name)
+}
+
+// HexCArrayFile writes in as a C unsigned char array named name, followed by
+// its length, as xxd -i does.
+func HexCArrayFile(in io.Reader, out io.Writer, charmap []byte, width int, name string) error {
+	if width <= 0 {
+		width = C_DEFAULT_WIDTH
+	}
+
+	reader := bufio.NewReader(in)
+	writer := bufio.NewWriter(out)
+	buf := make([]byte, width)
+
+	fmt.Fprintf(writer, "unsigned char %s[] = {\n", name)
+	total := 0
+	for {
+		n, err := readChunk(reader, buf)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		if n == 0 {
+			break
+		}
+
+		for i, b := range buf[:n] {
+			switch {
+			case total == 0 && i == 0:
+				writer.WriteString("  ")
+			case i == 0:
+				writer.WriteString(",\n  ")
+			default:
+				writer.WriteString(", ")
+			}
+
+			writer.Write([]byte{'0', 'x', charmap[b>>4], charmap[b&0x0f]})
+		}
+
+		total += n
+		if err != nil {
+			break
+		}
+	}
+
+	if total > 0 {
+		writer.WriteString("\n")
+	}
+
+	fmt.Fprintf(writer, "};\nunsigned int %s_len = %d;\n", name, total)
+	return writer.Flush()
+}
+
+// hexDecodeDigits decodes pairs of hex digits in text, whitespace is ignored.
+// A pending high digit is passed between calls in half.
+func hexDecodeDigits(text []byte, out io.Writer, half *int) error {
+	decoded := make([]byte, 0, len(text)/2)
+	for _, c := range text {
+		if isSpace(c) {
+			continue
+		}
+
+		v := HEX_DECODE_MAP[c]
+		if v == 0xff {
+			return fmt.Errorf("invalid hex character '%c'", c)
+		}
+
+		if *half < 0 {
+			*half = int(v)
+			continue
+		}
+
+		decoded = append(decoded, byte(*half<<4)|v)
+		*half = -1
+	}
+
+	_, err := out.Write(decoded)
+	return err
+}
+
+// HexDecodeFile converts plain hex digits back to binary.
+func HexDecodeFile(in io.Reader, out io.Writer) error {
+	reader := bufio.NewReader(in)
+	buf := make([]byte, 4096)
+	half := -1
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			if err := hexDecodeDigits(buf[:n], out, &half); err != nil {
+				return err
+			}
+		}
+
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return err
+			}
+			break
+		}
+	}
+
+	if half >= 0 {
+		return errors.New("odd number of hex digits")
+	}
+
+	return nil
+}
+
+// HexUndumpFile converts xxd-style dump lines back to binary, the offset
+// and ASCII columns are ignored.
+func HexUndumpFile(in io.Reader, out io.Writer) error {
+	reader := bufio.NewReader(in)
+	for lineNo := 1; ; lineNo++ {
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		text := strings.TrimRight(line, "\r\n")
+		if colon := strings.IndexByte(text, ':'); colon >= 0 {
+			text = text[colon+1:]
+			if strings.HasPrefix(text, " ") {
+				text = text[1:]
+			}
+
+			if sep := strings.Index(text, "  "); sep >= 0 {
+				text = text[:sep]
+			}
+
+			half := -1
+			if err := hexDecodeDigits([]byte(text), out, &half); err != nil {
+				return fmt.Errorf("line %d: %w", lineNo, err)
+			}
+
+			if half >= 0 {
+				return fmt.Errorf("line %d: odd number of hex digits", lineNo)
+			}
+
+		} else if strings.TrimSpace(text) != "" {
+			return fmt.Errorf("line %d: missing offset", lineNo)
+		}
+
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+// HexCArrayDecodeFile converts the 0x.. items of C arrays back to binary.
+func HexCArrayDecodeFile(in io.Reader, out io.Writer) error {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+
+	text := string(data)
+	for {
+		begin := strings.IndexByte(text, '{')
+		if begin < 0 {
+			return nil
+		}
+
+		end := strings.IndexByte(text[begin:], '}')
+		if end < 0 {
+			return errors.New("unterminated C array")
+		}
+
+		for _, item := range strings.Split(text[begin+1:begin+end], ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+
+			if !strings.HasPrefix(item, "0x") && !strings.HasPrefix(item, "0X") || len(item) > 4 {
+				return fmt.Errorf("invalid C array item '%s'", item)
+			}
+
+			half := -1
+			if len(item) == 3 {
+				half = 0
+			}
+
+			if err := hexDecodeDigits([]byte(item[2:]), out, &half); err != nil {
+				return err
+			}
+		}
+
+		text = text[begin+end+1:]
+	}
+}
+
+func openFile(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return os.Stdin, nil
+	}
+
+	return os.Open(name)
+}
+
+func usage() {
+	name := os.Args[0]
+	fmt.Printf("Usage: %s [-r] [-t plain|xxd|c] [-b bytes] file1 [file2 ...]\n", name)
+	flag.PrintDefaults()
+}
+
+type HexConfigure struct {
+	Reverse bool
+	Type    string
+	Width   int
+	Group   int
+	Upper   bool
+	Output  string
+	Name    string
+	Files   []string
+}
+
+func initFlags(conf *HexConfigure) {
+	flag.BoolVar(&conf.Reverse, "r", false, "reverse mode, convert hex back to binary")
+	flag.StringVar(&conf.Type, "t", "xxd", "output type, plain, xxd or c")
+	flag.IntVar(&conf.Width, "b", 0,
+		"bytes per line, 0 means no line break for plain, 16 for xxd and 12 for c")
+	flag.IntVar(&conf.Group, "g", DUMP_DEFAULT_GROUP, "bytes per group in xxd output")
+	flag.BoolVar(&conf.Upper, "u", false, "use upper case hex digits")
+	flag.StringVar(&conf.Output, "o", "", "output to file")
+	flag.StringVar(&conf.Name, "n", "", "name of C array, default to the input file name")
+}
+
+type FileEncodeHandler func(in io.Reader, filename string) error
+
+func makeHandler(conf *HexConfigure, out io.Writer) (FileEncodeHandler, error) {
+	charmap := HEX_ENCODE_LOWER_MAP
+	if conf.Upper {
+		charmap = HEX_ENCODE_UPPER_MAP
+	}
+
+	switch conf.Type {
+	case "plain":
+		if conf.Reverse {
+			return func(in io.Reader, _ string) error { return HexDecodeFile(in, out) }, nil
+		}
+		return func(in io.Reader, _ string) error { return HexEncodeFile(in, out, charmap, conf.Width) }, nil
+
+	case "xxd":
+		if conf.Reverse {
+			return func(in io.Reader, _ string) error { return HexUndumpFile(in, out) }, nil
+		}
+		return func(in io.Reader, _ string) error {
+			return HexDumpFile(in, out, charmap, conf.Width, conf.Group)
+		}, nil
+
+	case "c":
+		if conf.Reverse {
+			return func(in io.Reader, _ string) error { return HexCArrayDecodeFile(in, out) }, nil
+		}
+		return func(in io.Reader, filename string) error {
+			name := conf.Name
+			if name == "" {
+				name = CArrayName(filename)
+			}
+			return HexCArrayFile(in, out, charmap, conf.Width, name)
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown type '%s'", conf.Type)
+	}
+}
+
+// run processes all files in conf and returns the exit status, 1 if any
+// error occurs.
+func run(conf *HexConfigure) (status int) {
+	output := os.Stdout
+	if conf.Output != "" {
+		file, err := os.Create(conf.Output)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			return 1
+		}
+
+		output = file
+	}
+
+	out := bufio.NewWriter(output)
+	defer func() {
+		err := out.Flush()
+		if output != os.Stdout {
+			if errClose := output.Close(); err == nil {
+				err = errClose
+			}
+		}
+
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			status = 1
+		}
+	}()
+
+	handler, err := makeHandler(conf, out)
+	if err != nil {
+		fmt.Printf("ERROR: %s\n", err)
+		return 1
+	}
+
+	for _, filename := range conf.Files {
+		file, err := openFile(filename)
+		if err != nil {
+			fmt.Printf("Open file '%s' failed: %s\n", filename, err)
+			status = 1
+			continue
+		}
+
+		err = handler(file, filename)
+		file.Close()
+		if err != nil {
+			_ = out.Flush()
+			fmt.Printf("ERROR: %s\n", err)
+			status = 1
+		}
+	}
+
+	return status
+}
+
+func main() {
+	conf := &HexConfigure{}
+	initFlags(conf)
+	flag.Usage = usage
+	flag.Parse()
+
+	conf.Files = []string{"-"}
+	if flag.NArg() > 0 {
+		conf.Files = flag.Args()
+	}
+
+	os.Exit(run(conf))
+}
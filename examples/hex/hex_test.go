@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const hexTestText = "Hello, World!\nThis is xxd"
+
+func TestHexEncode(t *testing.T) {
+	cases := []struct {
+		width   int
+		charmap []byte
+		exp     string
+	}{
+		{0, HEX_ENCODE_LOWER_MAP, "48656c6c6f2c20576f726c64210a5468697320697320787864\n"},
+		{10, HEX_ENCODE_LOWER_MAP, "48656c6c6f2c20576f72\n6c64210a546869732069\n7320787864\n"},
+		{5, HEX_ENCODE_UPPER_MAP, "48656C6C6F\n2C20576F72\n6C64210A54\n6869732069\n7320787864\n"},
+	}
+
+	for i, c := range cases {
+		buf := bytes.NewBuffer(nil)
+		if err := HexEncodeFile(strings.NewReader(hexTestText), buf, c.charmap, c.width); err != nil {
+			t.Fatalf("case %d: encode failed: %s", i, err)
+		}
+
+		if buf.String() != c.exp {
+			t.Errorf("case %d: got %q; expected %q", i, buf.String(), c.exp)
+		}
+
+		decoded := bytes.NewBuffer(nil)
+		if err := HexDecodeFile(buf, decoded); err != nil {
+			t.Fatalf("case %d: decode failed: %s", i, err)
+		}
+
+		if decoded.String() != hexTestText {
+			t.Errorf("case %d: decoded %q; expected %q", i, decoded.String(), hexTestText)
+		}
+	}
+}
+
+func TestHexDecodeInvalid(t *testing.T) {
+	for _, s := range []string{"4x", "486", "48 6"} {
+		if err := HexDecodeFile(strings.NewReader(s), bytes.NewBuffer(nil)); err == nil {
+			t.Errorf("decode %q: expected an error", s)
+		}
+	}
+}
+
+func TestHexDump(t *testing.T) {
+	cases := []struct {
+		width int
+		group int
+		exp   string
+	}{
+		{0, 2, "" +
+			"00000000: 4865 6c6c 6f2c 2057 6f72 6c64 210a 5468  Hello, World!.Th\n" +
+			"00000010: 6973 2069 7320 7878 64                   is is xxd\n"},
+		{8, 4, "" +
+			"00000000: 48656c6c 6f2c2057  Hello, W\n" +
+			"00000008: 6f726c64 210a5468  orld!.Th\n" +
+			"00000010: 69732069 73207878  is is xx\n" +
+			"00000018: 64                 d\n"},
+	}
+
+	for i, c := range cases {
+		buf := bytes.NewBuffer(nil)
+		err := HexDumpFile(strings.NewReader(hexTestText), buf, HEX_ENCODE_LOWER_MAP, c.width, c.group)
+		if err != nil {
+			t.Fatalf("case %d: dump failed: %s", i, err)
+		}
+
+		if buf.String() != c.exp {
+			t.Errorf("case %d: got\n%s\nexpected\n%s", i, buf.String(), c.exp)
+		}
+
+		decoded := bytes.NewBuffer(nil)
+		if err := HexUndumpFile(buf, decoded); err != nil {
+			t.Fatalf("case %d: undump failed: %s", i, err)
+		}
+
+		if decoded.String() != hexTestText {
+			t.Errorf("case %d: undumped %q; expected %q", i, decoded.String(), hexTestText)
+		}
+	}
+}
+
+func TestHexCArray(t *testing.T) {
+	exp := "" +
+		"unsigned char _tmp_x_txt[] = {\n" +
+		"  0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x2c, 0x20, 0x57, 0x6f, 0x72, 0x6c, 0x64,\n" +
+		"  0x21, 0x0a, 0x54, 0x68, 0x69, 0x73, 0x20, 0x69, 0x73, 0x20, 0x78, 0x78,\n" +
+		"  0x64\n" +
+		"};\n" +
+		"unsigned int _tmp_x_txt_len = 25;\n"
+
+	buf := bytes.NewBuffer(nil)
+	name := CArrayName("/tmp/x.txt")
+	if err := HexCArrayFile(strings.NewReader(hexTestText), buf, HEX_ENCODE_LOWER_MAP, 0, name); err != nil {
+		t.Fatalf("encode failed: %s", err)
+	}
+
+	if buf.String() != exp {
+		t.Errorf("got\n%s\nexpected\n%s", buf.String(), exp)
+	}
+
+	decoded := bytes.NewBuffer(nil)
+	if err := HexCArrayDecodeFile(buf, decoded); err != nil {
+		t.Fatalf("decode failed: %s", err)
+	}
+
+	if decoded.String() != hexTestText {
+		t.Errorf("decoded %q; expected %q", decoded.String(), hexTestText)
+	}
+}
+
+func TestCArrayName(t *testing.T) {
+	cases := map[string]string{
+		"-":           "data",
+		"key.bin":     "key_bin",
+		"1st-file.gz": "__1st_file_gz",
+	}
+
+	for filename, exp := range cases {
+		if name := CArrayName(filename); name != exp {
+			t.Errorf("CArrayName(%q) = %q; expected %q", filename, name, exp)
+		}
+	}
+}